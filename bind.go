@@ -0,0 +1,134 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package otelgin // import "go.opentelemetry.io/contrib/instrumentation/github.com/gin-gonic/gin/otelgin"
+
+import (
+	"errors"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	otelmetric "go.opentelemetry.io/otel/metric"
+	semconv "go.opentelemetry.io/otel/semconv/v1.20.0"
+	oteltrace "go.opentelemetry.io/otel/trace"
+)
+
+const (
+	bindErrorsName = "gin.bind.errors"
+
+	bindKindKey        = attribute.Key("gin.bind.kind")
+	bindContentTypeKey = attribute.Key("gin.bind.content_type")
+	bindErrorKey       = attribute.Key("gin.bind.error")
+
+	validationFieldKey     = attribute.Key("validation.field")
+	validationTagKey       = attribute.Key("validation.tag")
+	validationNamespaceKey = attribute.Key("validation.namespace")
+)
+
+// ShouldBind will trace the binding of the request to obj as a child of the
+// span in the given context. This is a replacement for
+// gin.Context.ShouldBind function - it picks the binding engine the same
+// way and invokes ShouldBindWith.
+func ShouldBind(c *gin.Context, obj any) error {
+	return ShouldBindWith(c, obj, binding.Default(c.Request.Method, c.ContentType()))
+}
+
+// ShouldBindJSON is a replacement for gin.Context.ShouldBindJSON, see
+// ShouldBindWith.
+func ShouldBindJSON(c *gin.Context, obj any) error {
+	return ShouldBindWith(c, obj, binding.JSON)
+}
+
+// ShouldBindQuery is a replacement for gin.Context.ShouldBindQuery, see
+// ShouldBindWith.
+func ShouldBindQuery(c *gin.Context, obj any) error {
+	return ShouldBindWith(c, obj, binding.Query)
+}
+
+// ShouldBindUri is a replacement for gin.Context.ShouldBindUri, see
+// ShouldBindWith.
+func ShouldBindUri(c *gin.Context, obj any) error { // nolint:revive
+	return traceBind(c, binding.Uri.Name(), func() error {
+		return c.ShouldBindUri(obj)
+	})
+}
+
+// ShouldBindWith will trace the binding of the request to obj as a child of
+// the span in the given context. This is a replacement for
+// gin.Context.ShouldBindWith function - it invokes the original function
+// within a "gin.bind" span.
+//
+// Each entry of a validator.ValidationErrors returned by the binding becomes
+// a "validation_error" event carrying the field, tag and namespace of the
+// failed validation, but never the rejected value. Failed bindings are also
+// counted by the gin.bind.errors metric.
+func ShouldBindWith(c *gin.Context, obj any, b binding.Binding) error {
+	return traceBind(c, b.Name(), func() error {
+		return c.ShouldBindWith(obj, b)
+	})
+}
+
+// globalBindErrors counts the bind errors of the requests not served by the
+// middleware, with the global meter provider. It is created once, by
+// bindErrorsFromContext.
+var (
+	globalBindErrorsOnce sync.Once
+	globalBindErrors     otelmetric.Int64Counter
+)
+
+// bindErrorsFromContext returns the gin.bind.errors counter stored in c by
+// the middleware, or one from the global provider if the request was not
+// served by it.
+func bindErrorsFromContext(c *gin.Context) otelmetric.Int64Counter {
+	if v, ok := c.Get(bindErrorsKey); ok {
+		if counter, ok := v.(otelmetric.Int64Counter); ok {
+			return counter
+		}
+	}
+	globalBindErrorsOnce.Do(func() {
+		var cfg config
+		globalBindErrors = cfg.int64Counter(meterFromContext(c), bindErrorsInstrument)
+	})
+	return globalBindErrors
+}
+
+func traceBind(c *gin.Context, kind string, bind func() error) error {
+	kindAttr := bindKindKey.String(kind)
+	_, span := tracerFromContext(c).Start(c.Request.Context(), "gin.bind",
+		oteltrace.WithAttributes(kindAttr, bindContentTypeKey.String(c.ContentType())))
+	defer span.End()
+
+	err := bind()
+	if err == nil {
+		return nil
+	}
+
+	errAttr := bindErrorKey.String("decode")
+	var verrs validator.ValidationErrors
+	if errors.As(err, &verrs) {
+		errAttr = bindErrorKey.String("validation")
+		for _, fe := range verrs {
+			span.AddEvent("validation_error", oteltrace.WithAttributes(
+				validationFieldKey.String(fe.Field()),
+				validationTagKey.String(fe.Tag()),
+				validationNamespaceKey.String(fe.Namespace()),
+			))
+		}
+	} else {
+		span.RecordError(err)
+	}
+	span.SetAttributes(errAttr)
+	span.SetStatus(codes.Error, "binding failure")
+
+	attrs := []attribute.KeyValue{kindAttr, errAttr}
+	if route := c.FullPath(); route != "" {
+		attrs = append(attrs, semconv.HTTPRoute(route))
+	}
+	bindErrorsFromContext(c).Add(c.Request.Context(), one, otelmetric.WithAttributes(attrs...))
+	return err
+}
@@ -0,0 +1,139 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package otelgin
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+type bindUser struct {
+	Name  string `json:"name" binding:"required"`
+	Email string `json:"email" binding:"required,email"`
+}
+
+func TestShouldBindJSON(t *testing.T) {
+	sr := tracetest.NewSpanRecorder()
+	tracerProvider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(sr))
+	reader := metric.NewManualReader()
+	meterProvider := metric.NewMeterProvider(metric.WithReader(reader))
+	defer func() {
+		_ = meterProvider.Shutdown(context.Background())
+	}()
+
+	router := gin.New()
+	router.Use(Middleware("test-service",
+		WithTracerProvider(tracerProvider),
+		WithMeterProvider(meterProvider),
+	))
+	router.POST("/users", func(c *gin.Context) {
+		var u bindUser
+		if err := ShouldBindJSON(c, &u); err != nil {
+			c.Status(http.StatusBadRequest)
+			return
+		}
+		c.Status(http.StatusCreated)
+	})
+
+	body := `{"email":"not-an-email"}`
+	req := httptest.NewRequest("POST", "/users", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	spans := sr.Ended()
+	require.Len(t, spans, 2)
+	bind := spans[0]
+	assert.Equal(t, "gin.bind", bind.Name())
+	assert.Equal(t, spans[1].SpanContext().SpanID(), bind.Parent().SpanID())
+	assert.Equal(t, codes.Error, bind.Status().Code)
+	assert.Contains(t, bind.Attributes(), bindKindKey.String("json"))
+	assert.Contains(t, bind.Attributes(), bindContentTypeKey.String("application/json"))
+	assert.Contains(t, bind.Attributes(), bindErrorKey.String("validation"))
+
+	events := bind.Events()
+	require.Len(t, events, 2)
+	assert.Equal(t, "validation_error", events[0].Name)
+	assert.Equal(t, []attribute.KeyValue{
+		validationFieldKey.String("Name"),
+		validationTagKey.String("required"),
+		validationNamespaceKey.String("bindUser.Name"),
+	}, events[0].Attributes)
+	for _, e := range events {
+		for _, kv := range e.Attributes {
+			assert.NotContains(t, kv.Value.Emit(), "not-an-email")
+		}
+	}
+
+	var rm metricdata.ResourceMetrics
+	require.NoError(t, reader.Collect(context.Background(), &rm))
+	var bindErrors int64
+	for _, sm := range rm.ScopeMetrics {
+		for _, m := range sm.Metrics {
+			if m.Name != "gin.bind.errors" {
+				continue
+			}
+			for _, dp := range m.Data.(metricdata.Sum[int64]).DataPoints {
+				v, _ := dp.Attributes.Value("http.route")
+				assert.Equal(t, "/users", v.AsString())
+				bindErrors += dp.Value
+			}
+		}
+	}
+	assert.Equal(t, int64(1), bindErrors)
+}
+
+func TestShouldBindUri(t *testing.T) {
+	sr := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(sr))
+
+	router := gin.New()
+	router.Use(Middleware("test-service", WithTracerProvider(provider)))
+	router.GET("/users/:id", func(c *gin.Context) {
+		var uri struct {
+			ID int `uri:"id"`
+		}
+		assert.NoError(t, ShouldBindUri(c, &uri))
+		assert.Equal(t, 42, uri.ID)
+	})
+
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/users/42", nil))
+
+	spans := sr.Ended()
+	require.Len(t, spans, 2)
+	assert.Equal(t, "gin.bind", spans[0].Name())
+	assert.Equal(t, codes.Unset, spans[0].Status().Code)
+	assert.Contains(t, spans[0].Attributes(), bindKindKey.String("uri"))
+}
+
+func TestBindErrorsCounterCreatedOnce(t *testing.T) {
+	router := gin.New()
+	router.Use(Middleware("test-service"))
+	router.GET("/", func(c *gin.Context) {
+		_, ok := c.Get(bindErrorsKey)
+		assert.True(t, ok, "stored by the middleware")
+		assert.True(t, bindErrorsFromContext(c) == bindErrorsFromContext(c))
+	})
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil))
+
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	c.Request = httptest.NewRequest("GET", "/", nil)
+	assert.NotNil(t, bindErrorsFromContext(c))
+	assert.True(t, bindErrorsFromContext(c) == bindErrorsFromContext(c), "global counter created once")
+}
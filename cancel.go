@@ -0,0 +1,24 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package otelgin // import "go.opentelemetry.io/contrib/instrumentation/github.com/gin-gonic/gin/otelgin"
+
+import (
+	"context"
+	"errors"
+)
+
+const (
+	canceledReqsName = "http." + role + ".request.canceled"
+
+	// defaultClientCanceledErrorType is the error.type of requests canceled
+	// by their client.
+	defaultClientCanceledErrorType = "client_canceled"
+)
+
+// clientCanceled reports whether the request context ctx, as created by the
+// net/http server, was canceled, which happens when the client closes the
+// connection before the response is complete.
+func clientCanceled(ctx context.Context) bool {
+	return errors.Is(ctx.Err(), context.Canceled)
+}
@@ -0,0 +1,117 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package otelgin
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestClientCanceled(t *testing.T) {
+	sr := tracetest.NewSpanRecorder()
+	tracerProvider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(sr))
+	reader := metric.NewManualReader()
+	meterProvider := metric.NewMeterProvider(metric.WithReader(reader))
+	defer func() {
+		_ = meterProvider.Shutdown(context.Background())
+	}()
+
+	router := gin.New()
+	router.Use(Middleware("test-service",
+		WithTracerProvider(tracerProvider),
+		WithMeterProvider(meterProvider),
+	))
+	router.GET("/slow", func(c *gin.Context) {
+		<-c.Request.Context().Done()
+		c.Status(http.StatusInternalServerError)
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	r := httptest.NewRequest("GET", "/slow", nil).WithContext(ctx)
+	router.ServeHTTP(httptest.NewRecorder(), r)
+
+	spans := sr.Ended()
+	require.Len(t, spans, 1)
+	assert.Equal(t, codes.Unset, spans[0].Status().Code)
+	assert.Contains(t, spans[0].Attributes(), errorTypeKey.String("client_canceled"))
+
+	var rm metricdata.ResourceMetrics
+	require.NoError(t, reader.Collect(context.Background(), &rm))
+	var canceled int64
+	for _, sm := range rm.ScopeMetrics {
+		for _, m := range sm.Metrics {
+			switch m.Name {
+			case reqDurationName:
+				for _, dp := range m.Data.(metricdata.Histogram[float64]).DataPoints {
+					assert.False(t, dp.Attributes.HasValue("http.status_code"))
+					v, _ := dp.Attributes.Value(errorTypeKey)
+					assert.Equal(t, "client_canceled", v.AsString())
+				}
+			case canceledReqsName:
+				for _, dp := range m.Data.(metricdata.Sum[int64]).DataPoints {
+					canceled += dp.Value
+				}
+			}
+		}
+	}
+	assert.Equal(t, int64(1), canceled)
+}
+
+func TestClientCanceledAbortHandler(t *testing.T) {
+	sr := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(sr))
+
+	router := gin.New()
+	router.Use(Middleware("test-service",
+		WithTracerProvider(provider),
+		WithClientCanceledErrorType("client_gone"),
+	))
+	router.GET("/proxy", func(c *gin.Context) {
+		panic(http.ErrAbortHandler)
+	})
+
+	assert.PanicsWithValue(t, http.ErrAbortHandler, func() {
+		router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/proxy", nil))
+	})
+
+	spans := sr.Ended()
+	require.Len(t, spans, 1)
+	assert.Contains(t, spans[0].Attributes(), errorTypeKey.String("client_gone"))
+}
+
+func TestClientCanceledDisabled(t *testing.T) {
+	sr := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(sr))
+
+	router := gin.New()
+	router.Use(Middleware("test-service",
+		WithTracerProvider(provider),
+		WithClientCanceledErrorType(""),
+	))
+	router.GET("/slow", func(c *gin.Context) {
+		c.Status(http.StatusInternalServerError)
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/slow", nil).WithContext(ctx))
+
+	spans := sr.Ended()
+	require.Len(t, spans, 1)
+	assert.Equal(t, codes.Error, spans[0].Status().Code)
+	assert.Contains(t, spans[0].Attributes(), errorTypeKey.String("500"))
+}
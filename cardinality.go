@@ -0,0 +1,103 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package otelgin // import "go.opentelemetry.io/contrib/instrumentation/github.com/gin-gonic/gin/otelgin"
+
+import (
+	"context"
+	"sync"
+
+	"go.opentelemetry.io/otel/attribute"
+	otelmetric "go.opentelemetry.io/otel/metric"
+	semconv "go.opentelemetry.io/otel/semconv/v1.20.0"
+)
+
+const (
+	// overflowValue replaces the values of a limited attribute once its
+	// limit of distinct values has been reached.
+	overflowValue = "_OTHER"
+
+	overflowName = "otelgin.metric.attribute.overflow"
+)
+
+// cardinalityLimiter bounds the number of distinct values of some metric
+// attributes, per instrument. A nil *cardinalityLimiter does not limit
+// anything.
+type cardinalityLimiter struct {
+	valueLimit int
+	keys       map[attribute.Key]struct{}
+	overflow   otelmetric.Int64Counter
+
+	mu   sync.Mutex
+	seen map[string]map[attribute.Key]map[string]struct{}
+}
+
+func newCardinalityLimiter(overflow otelmetric.Int64Counter, limit int, keys []attribute.Key) *cardinalityLimiter {
+	if len(keys) == 0 {
+		keys = []attribute.Key{semconv.HTTPRouteKey, "gin.errors", errorTypeKey}
+	}
+	l := &cardinalityLimiter{
+		valueLimit: limit,
+		keys:       make(map[attribute.Key]struct{}, len(keys)),
+		overflow:   overflow,
+		seen:       make(map[string]map[attribute.Key]map[string]struct{}),
+	}
+	for _, k := range keys {
+		l.keys[k] = struct{}{}
+	}
+	return l
+}
+
+// limit returns attrs with the value of every limited attribute that is over
+// the limit for instrument replaced by overflowValue. attrs is not modified.
+func (l *cardinalityLimiter) limit(ctx context.Context, instrument string, attrs []attribute.KeyValue) []attribute.KeyValue {
+	if l == nil {
+		return attrs
+	}
+	var limited []attribute.KeyValue
+	for i, kv := range attrs {
+		if _, ok := l.keys[kv.Key]; !ok || l.admit(instrument, kv) {
+			continue
+		}
+		if limited == nil {
+			limited = make([]attribute.KeyValue, len(attrs))
+			copy(limited, attrs)
+		}
+		limited[i] = attribute.String(string(kv.Key), overflowValue)
+		l.overflow.Add(ctx, one, otelmetric.WithAttributes(
+			attribute.String("otelgin.instrument", instrument),
+			attribute.String("otelgin.attribute", string(kv.Key)),
+		))
+	}
+	if limited == nil {
+		return attrs
+	}
+	return limited
+}
+
+// admit reports whether the value of kv is known or can still be added to
+// the distinct values of kv.Key for instrument.
+func (l *cardinalityLimiter) admit(instrument string, kv attribute.KeyValue) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	byKey, ok := l.seen[instrument]
+	if !ok {
+		byKey = make(map[attribute.Key]map[string]struct{})
+		l.seen[instrument] = byKey
+	}
+	values, ok := byKey[kv.Key]
+	if !ok {
+		values = make(map[string]struct{})
+		byKey[kv.Key] = values
+	}
+	v := kv.Value.Emit()
+	if _, ok := values[v]; ok {
+		return true
+	}
+	if len(values) >= l.valueLimit {
+		return false
+	}
+	values[v] = struct{}{}
+	return true
+}
@@ -0,0 +1,64 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package otelgin
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+
+	"go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+)
+
+func TestMetricCardinalityLimit(t *testing.T) {
+	reader := metric.NewManualReader()
+	meterProvider := metric.NewMeterProvider(metric.WithReader(reader))
+	defer func() {
+		_ = meterProvider.Shutdown(context.Background())
+	}()
+
+	router := gin.New()
+	router.Use(Middleware("test-service",
+		WithMeterProvider(meterProvider),
+		WithSpanNameFormatter(func(r *http.Request) string { return r.URL.Path }),
+		WithMetricCardinalityLimit(2),
+	))
+	router.GET("/users/:id", func(c *gin.Context) {
+		c.String(http.StatusOK, "ok")
+	})
+
+	for _, path := range []string{"/users/1", "/users/2", "/users/3", "/users/4", "/users/1"} {
+		router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", path, nil))
+	}
+
+	var rm metricdata.ResourceMetrics
+	assert.NoError(t, reader.Collect(context.Background(), &rm))
+
+	routes := map[string]uint64{}
+	var overflow int64
+	for _, sm := range rm.ScopeMetrics {
+		for _, m := range sm.Metrics {
+			switch m.Name {
+			case reqDurationName:
+				for _, dp := range m.Data.(metricdata.Histogram[float64]).DataPoints {
+					v, _ := dp.Attributes.Value("http.route")
+					routes[v.AsString()] += dp.Count
+				}
+			case "otelgin.metric.attribute.overflow":
+				for _, dp := range m.Data.(metricdata.Sum[int64]).DataPoints {
+					if v, _ := dp.Attributes.Value("otelgin.instrument"); v.AsString() == reqDurationName {
+						overflow += dp.Value
+					}
+				}
+			}
+		}
+	}
+	assert.Equal(t, map[string]uint64{"/users/1": 2, "/users/2": 1, overflowValue: 2}, routes)
+	assert.Equal(t, int64(2), overflow)
+}
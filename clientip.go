@@ -0,0 +1,147 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package otelgin // import "go.opentelemetry.io/contrib/instrumentation/github.com/gin-gonic/gin/otelgin"
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	semconv "go.opentelemetry.io/otel/semconv/v1.20.0"
+)
+
+// ClientIPFunc returns the address of the client that originated a request,
+// or an empty string if it is unknown.
+type ClientIPFunc func(c *gin.Context) string
+
+// ginClientIP is the default ClientIPFunc. It relies on the trusted proxies,
+// remote IP headers and trusted platform configured on the gin.Engine. A
+// gin.Engine trusts every proxy until engine.SetTrustedProxies is called, so
+// by default the first X-Forwarded-For entry, which any client can set, is
+// returned. The Forwarded header is not parsed.
+func ginClientIP(c *gin.Context) string {
+	return c.ClientIP()
+}
+
+// trustedProxies is the otelgin own client address policy: the client is the
+// first address that is not a trusted proxy, walking the Forwarded or
+// X-Forwarded-For hops backwards from the peer of the connection.
+type trustedProxies []*net.IPNet
+
+// newTrustedProxies parses proxies, given as IP addresses or CIDR ranges.
+// Invalid entries are reported to the global error handler and skipped.
+func newTrustedProxies(proxies []string) trustedProxies {
+	nets := make(trustedProxies, 0, len(proxies))
+	for _, p := range proxies {
+		if !strings.Contains(p, "/") {
+			ip := net.ParseIP(p)
+			if ip == nil {
+				otel.Handle(fmt.Errorf("otelgin: invalid trusted proxy %q", p))
+				continue
+			}
+			bits := 8 * net.IPv6len
+			if ip4 := ip.To4(); ip4 != nil {
+				ip, bits = ip4, 8*net.IPv4len
+			}
+			nets = append(nets, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)})
+			continue
+		}
+		_, ipNet, err := net.ParseCIDR(p)
+		if err != nil {
+			otel.Handle(fmt.Errorf("otelgin: invalid trusted proxy %q: %w", p, err))
+			continue
+		}
+		nets = append(nets, ipNet)
+	}
+	return nets
+}
+
+func (t trustedProxies) trusted(ip net.IP) bool {
+	for _, n := range t {
+		if n.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+func (t trustedProxies) clientIP(c *gin.Context) string {
+	remote, _, err := net.SplitHostPort(strings.TrimSpace(c.Request.RemoteAddr))
+	if err != nil {
+		remote = strings.TrimSpace(c.Request.RemoteAddr)
+	}
+	client := net.ParseIP(remote)
+	if client == nil {
+		return ""
+	}
+	hops := forwardedFor(c.Request.Header)
+	for i := len(hops) - 1; i >= 0 && t.trusted(client); i-- {
+		hop := net.ParseIP(hops[i])
+		if hop == nil {
+			// An obfuscated or unknown hop ends the chain of
+			// addresses that can be relied upon.
+			break
+		}
+		client = hop
+	}
+	return client.String()
+}
+
+// forwardedFor returns the client addresses listed by the proxies of a
+// request, the originating client first. The RFC 7239 Forwarded header is
+// preferred over the X-Forwarded-For one.
+func forwardedFor(h http.Header) []string {
+	var hops []string
+	if values := h.Values("Forwarded"); len(values) > 0 {
+		for _, v := range values {
+			for _, elem := range strings.Split(v, ",") {
+				hops = append(hops, forwardedElementFor(elem))
+			}
+		}
+		return hops
+	}
+	for _, v := range h.Values("X-Forwarded-For") {
+		for _, hop := range strings.Split(v, ",") {
+			hops = append(hops, strings.TrimSpace(hop))
+		}
+	}
+	return hops
+}
+
+// forwardedElementFor returns the address of the "for" parameter of a
+// Forwarded header element, without its port, e.g. "2001:db8::1" for
+// `for="[2001:db8::1]:4711";proto=https`.
+func forwardedElementFor(elem string) string {
+	for _, pair := range strings.Split(elem, ";") {
+		k, v, ok := strings.Cut(strings.TrimSpace(pair), "=")
+		if !ok || !strings.EqualFold(k, "for") {
+			continue
+		}
+		v = strings.Trim(v, `"`)
+		if host, _, err := net.SplitHostPort(v); err == nil {
+			return host
+		}
+		return strings.TrimSuffix(strings.TrimPrefix(v, "["), "]")
+	}
+	return ""
+}
+
+// withClientIP returns attrs with its client IP attribute replaced by ip, or
+// dropped if ip is empty.
+func withClientIP(attrs []attribute.KeyValue, ip string) []attribute.KeyValue {
+	out := attrs[:0]
+	for _, kv := range attrs {
+		if kv.Key != semconv.HTTPClientIPKey {
+			out = append(out, kv)
+		}
+	}
+	if ip != "" {
+		out = append(out, semconv.HTTPClientIP(ip))
+	}
+	return out
+}
@@ -0,0 +1,129 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package otelgin
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"go.opentelemetry.io/otel/attribute"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestTrustedProxiesClientIP(t *testing.T) {
+	proxies := newTrustedProxies([]string{"10.0.0.0/8", "192.168.1.1", "not-an-ip"})
+	require.Len(t, proxies, 2)
+
+	tests := []struct {
+		name    string
+		remote  string
+		headers map[string]string
+		want    string
+	}{
+		{
+			name:   "untrusted peer ignores headers",
+			remote: "203.0.113.7:1234",
+			headers: map[string]string{
+				"X-Forwarded-For": "198.51.100.1",
+			},
+			want: "203.0.113.7",
+		},
+		{
+			name:   "spoofed leftmost entry is skipped",
+			remote: "10.0.0.1:1234",
+			headers: map[string]string{
+				"X-Forwarded-For": "1.2.3.4, 198.51.100.1, 192.168.1.1",
+			},
+			want: "198.51.100.1",
+		},
+		{
+			name:   "forwarded preferred over x-forwarded-for",
+			remote: "10.0.0.1:1234",
+			headers: map[string]string{
+				"Forwarded":       `for=198.51.100.2;proto=https, for="[2001:db8::1]:4711"`,
+				"X-Forwarded-For": "198.51.100.1",
+			},
+			want: "2001:db8::1",
+		},
+		{
+			name:   "obfuscated hop ends the chain",
+			remote: "10.0.0.1:1234",
+			headers: map[string]string{
+				"Forwarded": "for=198.51.100.2, for=_hidden",
+			},
+			want: "10.0.0.1",
+		},
+		{
+			name:   "all hops trusted",
+			remote: "10.0.0.1:1234",
+			headers: map[string]string{
+				"X-Forwarded-For": "10.1.1.1",
+			},
+			want: "10.1.1.1",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, _ := gin.CreateTestContext(httptest.NewRecorder())
+			c.Request = httptest.NewRequest("GET", "/", nil)
+			c.Request.RemoteAddr = tt.remote
+			for k, v := range tt.headers {
+				c.Request.Header.Set(k, v)
+			}
+			assert.Equal(t, tt.want, proxies.clientIP(c))
+		})
+	}
+}
+
+func TestClientIPFromEngine(t *testing.T) {
+	sr := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(sr))
+
+	router := gin.New()
+	require.NoError(t, router.SetTrustedProxies(nil))
+	router.Use(Middleware("test-service", WithTracerProvider(provider)))
+	router.GET("/ping", func(c *gin.Context) {})
+
+	r := httptest.NewRequest("GET", "/ping", nil)
+	r.RemoteAddr = "203.0.113.7:1234"
+	r.Header.Set("X-Forwarded-For", "1.2.3.4")
+	router.ServeHTTP(httptest.NewRecorder(), r)
+
+	spans := sr.Ended()
+	require.Len(t, spans, 1)
+	attrs := attribute.NewSet(spans[0].Attributes()...)
+	v, ok := attrs.Value("http.client_ip")
+	assert.True(t, ok)
+	assert.Equal(t, "203.0.113.7", v.AsString())
+}
+
+func TestClientIPFromDefaultEngine(t *testing.T) {
+	sr := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(sr))
+
+	// A default engine trusts every proxy, so the client controls the
+	// address through X-Forwarded-For.
+	router := gin.New()
+	router.Use(Middleware("test-service", WithTracerProvider(provider)))
+	router.GET("/ping", func(c *gin.Context) {})
+
+	r := httptest.NewRequest("GET", "/ping", nil)
+	r.RemoteAddr = "203.0.113.7:1234"
+	r.Header.Set("X-Forwarded-For", "1.2.3.4")
+	r.Header.Set("Forwarded", "for=5.6.7.8")
+	router.ServeHTTP(httptest.NewRecorder(), r)
+
+	spans := sr.Ended()
+	require.Len(t, spans, 1)
+	attrs := attribute.NewSet(spans[0].Attributes()...)
+	v, ok := attrs.Value("http.client_ip")
+	assert.True(t, ok)
+	assert.Equal(t, "1.2.3.4", v.AsString())
+}
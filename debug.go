@@ -0,0 +1,337 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package otelgin // import "go.opentelemetry.io/contrib/instrumentation/github.com/gin-gonic/gin/otelgin"
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"reflect"
+	"runtime"
+	"sort"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel"
+)
+
+// debugBounds are the upper bounds, in milliseconds, of the buckets of the
+// in-process request duration histograms used to estimate percentiles.
+var debugBounds = [...]float64{1, 2, 5, 10, 25, 50, 100, 250, 500, 1000, 2500, 5000, 10000, 30000, 60000}
+
+// DebugConfig is the effective configuration of a Middleware.
+type DebugConfig struct {
+	TracerProvider             string            `json:"tracer_provider"`
+	MeterProvider              string            `json:"meter_provider"`
+	LoggerProvider             string            `json:"logger_provider"`
+	Propagators                string            `json:"propagators"`
+	PropagatorFields           []string          `json:"propagator_fields"`
+	Filters                    int               `json:"filters"`
+	TraceFilters               int               `json:"trace_filters"`
+	MetricFilters              int               `json:"metric_filters"`
+	SpanNameFormatter          string            `json:"span_name_formatter,omitempty"`
+	ErrorClassifier            string            `json:"error_classifier"`
+	ClientIPFunc               string            `json:"client_ip_func"`
+	SpanStatusFunc             string            `json:"span_status_func,omitempty"`
+	RouteSpanStatusFuncs       []string          `json:"route_span_status_funcs,omitempty"`
+	SpanAttributesFns          int               `json:"span_attributes_fns"`
+	MetricAttributesFns        int               `json:"metric_attributes_fns"`
+	BaggageSpanKeys            []string          `json:"baggage_span_keys,omitempty"`
+	BaggageMetricKeys          []string          `json:"baggage_metric_keys,omitempty"`
+	CardinalityLimit           int               `json:"cardinality_limit,omitempty"`
+	RecordURLQuery             bool              `json:"record_url_query"`
+	RouteParams                bool              `json:"route_params"`
+	TLSAttributes              bool              `json:"tls_attributes"`
+	TLSMetricAttributes        bool              `json:"tls_metric_attributes"`
+	QueueTime                  bool              `json:"queue_time"`
+	TimeToFirstByte            bool              `json:"time_to_first_byte"`
+	ClientCanceledError        string            `json:"client_canceled_error_type,omitempty"`
+	Timeout                    string            `json:"timeout,omitempty"`
+	SLOThreshold               string            `json:"slo_threshold,omitempty"`
+	RouteSLOThresholds         map[string]string `json:"route_slo_thresholds,omitempty"`
+	SlowRequestThreshold       string            `json:"slow_request_threshold,omitempty"`
+	RouteSlowRequestThresholds map[string]string `json:"route_slow_request_thresholds,omitempty"`
+	ProfilerLabels             bool              `json:"profiler_labels"`
+	InflightRequests           bool              `json:"inflight_requests"`
+	RequestIDHeader            string            `json:"request_id_header,omitempty"`
+}
+
+// DebugInstrument describes an instrument recorded by a Middleware.
+type DebugInstrument struct {
+	Name string `json:"name"`
+	Kind string `json:"kind"`
+	Unit string `json:"unit"`
+}
+
+// DebugRouteStats are the in-process statistics of the requests matching a
+// route, or matching no route if Route is empty.
+type DebugRouteStats struct {
+	Route    string `json:"route"`
+	Requests int64  `json:"requests"`
+	Errors   int64  `json:"errors"`
+	// Filtered counts the requests rejected by the filters of either
+	// signal. The ones still traced or metered are counted in Requests
+	// too.
+	Filtered int64   `json:"filtered"`
+	P50      float64 `json:"p50_ms"`
+	P99      float64 `json:"p99_ms"`
+}
+
+// DebugReport reports what a Middleware created with the WithDebugStats
+// option is doing.
+type DebugReport struct {
+	Service     string            `json:"service"`
+	Config      DebugConfig       `json:"config"`
+	Instruments []DebugInstrument `json:"instruments"`
+	Routes      []DebugRouteStats `json:"routes"`
+}
+
+// routeStats are the statistics kept about a route.
+type routeStats struct {
+	requests int64
+	errors   int64
+	filtered int64
+	buckets  [len(debugBounds) + 1]int64
+}
+
+// percentile estimates the p-th percentile, 0 < p <= 1, of the durations
+// recorded in s by linear interpolation within their bucket.
+func (s *routeStats) percentile(p float64) float64 {
+	var total int64
+	for _, n := range s.buckets {
+		total += n
+	}
+	if total == 0 {
+		return 0
+	}
+	rank := p * float64(total)
+	var cumulative int64
+	for i, n := range s.buckets {
+		if n == 0 || float64(cumulative+n) < rank {
+			cumulative += n
+			continue
+		}
+		lower := 0.0
+		if i > 0 {
+			lower = debugBounds[i-1]
+		}
+		if i == len(debugBounds) {
+			// The last bucket has no upper bound.
+			return lower
+		}
+		return lower + (debugBounds[i]-lower)*(rank-float64(cumulative))/float64(n)
+	}
+	return debugBounds[len(debugBounds)-1]
+}
+
+// debugStats keeps the report of a Middleware.
+type debugStats struct {
+	service     string
+	config      DebugConfig
+	instruments []DebugInstrument
+
+	mu     sync.Mutex
+	routes map[string]*routeStats
+}
+
+// debugRegistry keeps the debugStats of all the Middleware created with the
+// WithDebugStats option. They are never removed.
+var debugRegistry struct {
+	mu    sync.Mutex
+	stats []*debugStats
+}
+
+// newDebugStats returns the debugStats of a Middleware of service configured
+// with cfg, registered to be reported by DebugHandler.
+func newDebugStats(service string, cfg *config) *debugStats {
+	d := &debugStats{
+		service:     service,
+		config:      cfg.debugConfig(),
+		instruments: cfg.instruments,
+		routes:      make(map[string]*routeStats),
+	}
+	debugRegistry.mu.Lock()
+	debugRegistry.stats = append(debugRegistry.stats, d)
+	debugRegistry.mu.Unlock()
+	return d
+}
+
+// route returns the statistics of route. d.mu must be held.
+func (d *debugStats) route(route string) *routeStats {
+	s, ok := d.routes[route]
+	if !ok {
+		s = &routeStats{}
+		d.routes[route] = s
+	}
+	return s
+}
+
+// record records a request served for route in elapsed. It is a no-op if d
+// is nil.
+func (d *debugStats) record(route string, elapsed time.Duration, failed bool) {
+	if d == nil {
+		return
+	}
+	ms := float64(elapsed) / float64(time.Millisecond)
+	i := sort.SearchFloat64s(debugBounds[:], ms)
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	s := d.route(route)
+	s.requests++
+	if failed {
+		s.errors++
+	}
+	s.buckets[i]++
+}
+
+// filter records a request for route rejected by the filters of any signal.
+// It is a no-op if d is nil.
+func (d *debugStats) filter(route string) {
+	if d == nil {
+		return
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.route(route).filtered++
+}
+
+// report returns the report of d.
+func (d *debugStats) report() DebugReport {
+	r := DebugReport{
+		Service:     d.service,
+		Config:      d.config,
+		Instruments: d.instruments,
+		Routes:      []DebugRouteStats{},
+	}
+	d.mu.Lock()
+	for route, s := range d.routes {
+		r.Routes = append(r.Routes, DebugRouteStats{
+			Route:    route,
+			Requests: s.requests,
+			Errors:   s.errors,
+			Filtered: s.filtered,
+			P50:      round(s.percentile(0.5)),
+			P99:      round(s.percentile(0.99)),
+		})
+	}
+	d.mu.Unlock()
+	sort.Slice(r.Routes, func(i, j int) bool {
+		return r.Routes[i].Route < r.Routes[j].Route
+	})
+	return r
+}
+
+// round rounds ms to the microsecond.
+func round(ms float64) float64 {
+	return math.Round(ms*1000) / 1000
+}
+
+// DebugReports returns the reports of the Middleware created with the
+// WithDebugStats option, in creation order.
+func DebugReports() []DebugReport {
+	debugRegistry.mu.Lock()
+	stats := append([]*debugStats(nil), debugRegistry.stats...)
+	debugRegistry.mu.Unlock()
+	reports := make([]DebugReport, 0, len(stats))
+	for _, d := range stats {
+		reports = append(reports, d.report())
+	}
+	return reports
+}
+
+// DebugHandler returns an http.Handler responding with the reports of the
+// Middleware created with the WithDebugStats option as a JSON object with a
+// "middlewares" array: their effective configuration, the names and units of
+// their instruments and their in-process statistics by route. It is meant to
+// be mounted on an admin-only route, e.g. with gin.WrapH.
+func DebugHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		body := struct {
+			Middlewares []DebugReport `json:"middlewares"`
+		}{DebugReports()}
+		if err := json.NewEncoder(w).Encode(body); err != nil {
+			otel.Handle(err)
+		}
+	})
+}
+
+// debugConfig returns the effective configuration reported for c.
+func (c *config) debugConfig() DebugConfig {
+	dc := DebugConfig{
+		TracerProvider:      typeName(c.TracerProvider),
+		MeterProvider:       typeName(c.MeterProvider),
+		LoggerProvider:      typeName(c.LoggerProvider),
+		Propagators:         typeName(c.Propagators),
+		PropagatorFields:    c.Propagators.Fields(),
+		Filters:             len(c.Filters),
+		TraceFilters:        len(c.TraceFilters),
+		MetricFilters:       len(c.MetricFilters),
+		SpanNameFormatter:   funcName(c.SpanNameFormatter),
+		ErrorClassifier:     funcName(c.ErrorClassifier),
+		ClientIPFunc:        funcName(c.ClientIPFunc),
+		SpanStatusFunc:      funcName(c.SpanStatusFunc),
+		SpanAttributesFns:   len(c.SpanAttributesFns),
+		MetricAttributesFns: len(c.MetricAttributesFns),
+		BaggageSpanKeys:     c.BaggageSpanKeys,
+		BaggageMetricKeys:   c.BaggageMetricKeys,
+		CardinalityLimit:    c.CardinalityLimit,
+		RecordURLQuery:      c.RecordURLQuery,
+		RouteParams:         c.RouteParams != nil,
+		TLSAttributes:       c.TLSAttributes,
+		TLSMetricAttributes: c.TLSMetricAttributes,
+		QueueTime:           c.QueueTime,
+		TimeToFirstByte:     c.TimeToFirstByte,
+		ClientCanceledError: c.ClientCanceledErrorType,
+		ProfilerLabels:      c.ProfilerLabels,
+		InflightRequests:    c.InflightRequests,
+		RequestIDHeader:     c.RequestIDHeader,
+	}
+	for route := range c.RouteSpanStatusFuncs {
+		dc.RouteSpanStatusFuncs = append(dc.RouteSpanStatusFuncs, route)
+	}
+	sort.Strings(dc.RouteSpanStatusFuncs)
+	if c.Timeout > 0 {
+		dc.Timeout = c.Timeout.String()
+	}
+	if c.SLOThreshold > 0 {
+		dc.SLOThreshold = c.SLOThreshold.String()
+	}
+	dc.RouteSLOThresholds = durations(c.RouteSLOThresholds)
+	if c.SlowRequestThreshold > 0 {
+		dc.SlowRequestThreshold = c.SlowRequestThreshold.String()
+	}
+	dc.RouteSlowRequestThresholds = durations(c.RouteSlowRequestThresholds)
+	return dc
+}
+
+// typeName returns the dynamic type name of v.
+func typeName(v any) string {
+	return fmt.Sprintf("%T", v)
+}
+
+// funcName returns the name of the function f, or "" if f is nil.
+func funcName(f any) string {
+	v := reflect.ValueOf(f)
+	if v.Kind() != reflect.Func || v.IsNil() {
+		return ""
+	}
+	if fn := runtime.FuncForPC(v.Pointer()); fn != nil {
+		return fn.Name()
+	}
+	return ""
+}
+
+// durations returns m with its durations formatted, or nil if m is empty.
+func durations(m map[string]time.Duration) map[string]string {
+	if len(m) == 0 {
+		return nil
+	}
+	out := make(map[string]string, len(m))
+	for k, d := range m {
+		out[k] = d.String()
+	}
+	return out
+}
@@ -0,0 +1,152 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package otelgin
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+)
+
+func TestDebugHandler(t *testing.T) {
+	router := gin.New()
+	router.Use(Middleware("debug-service",
+		WithDebugStats(),
+		WithFilter(func(r *http.Request) bool { return r.URL.Path != "/health" }),
+		WithSLO(time.Second),
+		WithTimeout(time.Minute),
+	))
+	router.GET("/ok", func(c *gin.Context) { c.Status(http.StatusOK) })
+	router.GET("/fail", func(c *gin.Context) { c.Status(http.StatusInternalServerError) })
+	router.GET("/health", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	for _, path := range []string{"/ok", "/ok", "/fail", "/health"} {
+		router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", path, nil))
+	}
+
+	w := httptest.NewRecorder()
+	DebugHandler().ServeHTTP(w, httptest.NewRequest("GET", "/debug", nil))
+	assert.Equal(t, "application/json", w.Header().Get("Content-Type"))
+	var body struct {
+		Middlewares []DebugReport `json:"middlewares"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+
+	var report *DebugReport
+	for i := range body.Middlewares {
+		if body.Middlewares[i].Service == "debug-service" {
+			report = &body.Middlewares[i]
+		}
+	}
+	require.NotNil(t, report)
+
+	assert.Equal(t, 1, report.Config.Filters)
+	assert.Equal(t, "1m0s", report.Config.Timeout)
+	assert.Equal(t, "1s", report.Config.SLOThreshold)
+	assert.Equal(t, "github.com/Cyprinus12138/otelgin.defaultErrorClassifier", report.Config.ErrorClassifier)
+	assert.Equal(t, "github.com/Cyprinus12138/otelgin.ginClientIP", report.Config.ClientIPFunc)
+	assert.Contains(t, report.Instruments, DebugInstrument{Name: reqDurationName, Kind: "histogram", Unit: "ms"})
+	assert.Contains(t, report.Instruments, DebugInstrument{Name: sloReqsName, Kind: "counter", Unit: "{request}"})
+	assert.NotContains(t, report.Instruments, DebugInstrument{Name: queueTimeName, Kind: "histogram", Unit: "ms"})
+
+	require.Len(t, report.Routes, 3)
+	assert.Equal(t, "/fail", report.Routes[0].Route)
+	assert.Equal(t, int64(1), report.Routes[0].Requests)
+	assert.Equal(t, int64(1), report.Routes[0].Errors)
+	assert.Equal(t, DebugRouteStats{Route: "/health", Filtered: 1}, report.Routes[1])
+	assert.Equal(t, "/ok", report.Routes[2].Route)
+	assert.Equal(t, int64(2), report.Routes[2].Requests)
+	assert.Zero(t, report.Routes[2].Errors)
+	assert.LessOrEqual(t, report.Routes[2].P50, report.Routes[2].P99)
+}
+
+func TestRouteStatsPercentile(t *testing.T) {
+	var s routeStats
+	assert.Zero(t, s.percentile(0.5))
+
+	// 100 requests evenly spread between 10ms and 25ms.
+	s.buckets[4] = 100
+	assert.InDelta(t, 17.5, s.percentile(0.5), 1e-9)
+	assert.InDelta(t, 24.85, s.percentile(0.99), 1e-9)
+
+	// Requests beyond the last bound.
+	s.buckets[len(debugBounds)] = 100
+	assert.Equal(t, debugBounds[len(debugBounds)-1], s.percentile(0.99))
+}
+
+func TestDebugInstrumentsMatchMeter(t *testing.T) {
+	reader := metric.NewManualReader()
+	meterProvider := metric.NewMeterProvider(metric.WithReader(reader))
+	defer func() {
+		_ = meterProvider.Shutdown(context.Background())
+	}()
+
+	router := gin.New()
+	router.Use(Middleware("debug-instruments-service",
+		WithMeterProvider(meterProvider),
+		WithDebugStats(),
+		WithSLO(time.Second),
+		WithMetricCardinalityLimit(1),
+	))
+	router.GET("/a", func(c *gin.Context) {})
+	router.GET("/b", func(c *gin.Context) {})
+	for _, path := range []string{"/a", "/b"} {
+		router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", path, nil))
+	}
+
+	var report *DebugReport
+	for _, r := range DebugReports() {
+		if r.Service == "debug-instruments-service" {
+			report = &r
+		}
+	}
+	require.NotNil(t, report)
+	units := map[string]string{}
+	for _, i := range report.Instruments {
+		units[i.Name] = i.Unit
+	}
+
+	var rm metricdata.ResourceMetrics
+	require.NoError(t, reader.Collect(context.Background(), &rm))
+	require.Len(t, rm.ScopeMetrics, 1)
+	var recorded []string
+	for _, m := range rm.ScopeMetrics[0].Metrics {
+		recorded = append(recorded, m.Name)
+		assert.Equal(t, m.Unit, units[m.Name], m.Name)
+	}
+	assert.Contains(t, recorded, overflowName)
+	assert.Contains(t, recorded, sloReqsName)
+}
+
+func TestDebugFilteredBySignal(t *testing.T) {
+	rejectHealth := func(r *http.Request) bool { return r.URL.Path != "/health" }
+	router := gin.New()
+	router.Use(Middleware("debug-signal-filter-service",
+		WithDebugStats(),
+		WithTraceFilter(rejectHealth),
+	))
+	router.GET("/health", func(c *gin.Context) {})
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/health", nil))
+
+	var report *DebugReport
+	for _, r := range DebugReports() {
+		if r.Service == "debug-signal-filter-service" {
+			report = &r
+		}
+	}
+	require.NotNil(t, report)
+	require.Len(t, report.Routes, 1)
+	assert.Equal(t, int64(1), report.Routes[0].Filtered)
+	assert.Equal(t, int64(1), report.Routes[0].Requests)
+}
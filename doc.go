@@ -3,8 +3,9 @@
 
 // Package otelgin instruments the github.com/gin-gonic/gin package.
 //
-// Currently there are two ways the code can be instrumented. One is
-// instrumenting the routing of a received message (the Middleware function)
-// and instrumenting the response generation through template evaluation (the
-// HTML function).
+// Currently there are three ways the code can be instrumented. One is
+// instrumenting the routing of a received message (the Middleware function),
+// another is instrumenting the response generation through template
+// evaluation (the HTML function), and the last is instrumenting the binding
+// of the request to a value (the ShouldBind family of functions).
 package otelgin // import "go.opentelemetry.io/contrib/instrumentation/github.com/gin-gonic/gin/otelgin"
@@ -0,0 +1,31 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package otelgin // import "go.opentelemetry.io/contrib/instrumentation/github.com/gin-gonic/gin/otelgin"
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+const errorTypeKey = attribute.Key("error.type")
+
+// ErrorClassifier returns the `error.type` of a served request, or an empty
+// string if the request did not fail.
+type ErrorClassifier func(c *gin.Context) string
+
+// defaultErrorClassifier classifies 5xx responses by their status code, and
+// other requests with errors by the Go type of the last one, e.g.
+// "*json.SyntaxError".
+func defaultErrorClassifier(c *gin.Context) string {
+	if status := c.Writer.Status(); status >= 500 {
+		return strconv.Itoa(status)
+	}
+	if err := c.Errors.Last(); err != nil {
+		return fmt.Sprintf("%T", err.Err)
+	}
+	return ""
+}
@@ -0,0 +1,110 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package otelgin
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+var errQuota = errors.New("quota exceeded")
+
+func TestErrorType(t *testing.T) {
+	tests := []struct {
+		name       string
+		opts       []Option
+		handler    gin.HandlerFunc
+		expectType string
+	}{
+		{
+			name:    "success",
+			handler: func(c *gin.Context) { c.Status(http.StatusOK) },
+		},
+		{
+			name:       "server error",
+			handler:    func(c *gin.Context) { c.Status(http.StatusBadGateway) },
+			expectType: "502",
+		},
+		{
+			name: "gin error",
+			handler: func(c *gin.Context) {
+				_ = c.Error(errQuota)
+				c.Status(http.StatusTooManyRequests)
+			},
+			expectType: "*errors.errorString",
+		},
+		{
+			name: "classifier",
+			opts: []Option{WithErrorClassifier(func(c *gin.Context) string {
+				if errors.Is(c.Errors.Last(), errQuota) {
+					return "quota"
+				}
+				return ""
+			})},
+			handler: func(c *gin.Context) {
+				_ = c.Error(errQuota)
+				c.Status(http.StatusTooManyRequests)
+			},
+			expectType: "quota",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sr := tracetest.NewSpanRecorder()
+			tracerProvider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(sr))
+			reader := metric.NewManualReader()
+			meterProvider := metric.NewMeterProvider(metric.WithReader(reader))
+			defer func() {
+				_ = meterProvider.Shutdown(context.Background())
+			}()
+
+			router := gin.New()
+			router.Use(Middleware("test-service", append(tt.opts,
+				WithTracerProvider(tracerProvider),
+				WithMeterProvider(meterProvider),
+			)...))
+			router.GET("/ping", tt.handler)
+			router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/ping", nil))
+
+			spans := sr.Ended()
+			require.Len(t, spans, 1)
+			attrs := attribute.NewSet(spans[0].Attributes()...)
+			v, ok := attrs.Value(errorTypeKey)
+			assert.Equal(t, tt.expectType != "", ok)
+			assert.Equal(t, tt.expectType, v.AsString())
+
+			var rm metricdata.ResourceMetrics
+			require.NoError(t, reader.Collect(context.Background(), &rm))
+			found := false
+			for _, sm := range rm.ScopeMetrics {
+				for _, m := range sm.Metrics {
+					if m.Name != reqDurationName {
+						continue
+					}
+					for _, dp := range m.Data.(metricdata.Histogram[float64]).DataPoints {
+						found = true
+						v, ok := dp.Attributes.Value(errorTypeKey)
+						assert.Equal(t, tt.expectType != "", ok)
+						assert.Equal(t, tt.expectType, v.AsString())
+					}
+				}
+			}
+			assert.True(t, found, "Expected a request duration data point")
+		})
+	}
+}
@@ -7,40 +7,52 @@ package otelgin // import "go.opentelemetry.io/contrib/instrumentation/github.co
 
 import (
 	"bytes"
+	"context"
 	"fmt"
 	"io"
 	"net/http"
 	"time"
 
 	otelmetric "go.opentelemetry.io/otel/metric"
-	"go.opentelemetry.io/otel/metric/noop"
 
 	"github.com/gin-gonic/gin"
 
 	"github.com/Cyprinus12138/otelgin/internal/semconvutil"
 	"go.opentelemetry.io/otel"
 	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/baggage"
 	"go.opentelemetry.io/otel/codes"
+	otellog "go.opentelemetry.io/otel/log"
+	"go.opentelemetry.io/otel/log/global"
 	"go.opentelemetry.io/otel/propagation"
 	semconv "go.opentelemetry.io/otel/semconv/v1.20.0"
 	oteltrace "go.opentelemetry.io/otel/trace"
+	tracenoop "go.opentelemetry.io/otel/trace/noop"
 )
 
 const (
-	tracerKey = "otel-go-contrib-tracer"
-	meterKey  = "otel-go-contrib-meter"
+	tracerKey     = "otel-go-contrib-tracer"
+	meterKey      = "otel-go-contrib-meter"
+	bindErrorsKey = "otel-go-contrib-bind-errors"
 	// ScopeName is the instrumentation scope name.
 	ScopeName = "github.com/Cyprinus12138/otelgin"
 	role      = "server"
 	one       = 1
+
+	reqDurationName = "http." + role + ".request.duration"
+	reqSizeName     = "http." + role + ".request.body.size"
+	respSizeName    = "http." + role + ".response.body.size"
+	activeReqsName  = "http." + role + ".active_requests"
 )
 
 // Middleware returns middleware that will trace incoming requests.
 // The service parameter should describe the name of the (virtual)
 // server handling the request.
 func Middleware(service string, opts ...Option) gin.HandlerFunc {
-	var err error
-	cfg := config{}
+	cfg := config{
+		ClientCanceledErrorType: defaultClientCanceledErrorType,
+		SlowRequestStackSize:    defaultSlowRequestStackSize,
+	}
 	for _, opt := range opts {
 		opt.apply(&cfg)
 	}
@@ -61,45 +73,50 @@ func Middleware(service string, opts ...Option) gin.HandlerFunc {
 	if cfg.Propagators == nil {
 		cfg.Propagators = otel.GetTextMapPropagator()
 	}
-
-	cfg.reqDuration, err = meter.Float64Histogram("http."+role+".request.duration",
-		otelmetric.WithDescription("Measures the duration of inbound RPC."),
-		otelmetric.WithUnit("ms"))
-	if err != nil {
-		otel.Handle(err)
-		if cfg.reqDuration == nil {
-			cfg.reqDuration = noop.Float64Histogram{}
-		}
+	if cfg.LoggerProvider == nil {
+		cfg.LoggerProvider = global.GetLoggerProvider()
 	}
-
-	cfg.reqSize, err = meter.Int64UpDownCounter("http."+role+".request.body.size",
-		otelmetric.WithDescription("Measures size of RPC request messages (uncompressed)."),
-		otelmetric.WithUnit("By"))
-	if err != nil {
-		otel.Handle(err)
-		if cfg.reqSize == nil {
-			cfg.reqSize = noop.Int64UpDownCounter{}
-		}
+	cfg.logger = cfg.LoggerProvider.Logger(
+		ScopeName,
+		otellog.WithInstrumentationVersion(Version()),
+	)
+	if cfg.ErrorClassifier == nil {
+		cfg.ErrorClassifier = defaultErrorClassifier
+	}
+	if cfg.ClientIPFunc == nil {
+		cfg.ClientIPFunc = ginClientIP
+	}
+	if cfg.RequestIDHeader != "" && cfg.RequestIDGenerator == nil {
+		cfg.RequestIDGenerator = newRequestID
 	}
 
-	cfg.respSize, err = meter.Int64UpDownCounter("http."+role+".response.body.size",
-		otelmetric.WithDescription("Measures size of RPC response messages (uncompressed)."),
-		otelmetric.WithUnit("By"))
-	if err != nil {
-		otel.Handle(err)
-		if cfg.respSize == nil {
-			cfg.respSize = noop.Int64UpDownCounter{}
-		}
+	cfg.reqDuration = cfg.float64Histogram(meter, reqDurationInstrument)
+	cfg.reqSize = cfg.int64UpDownCounter(meter, reqSizeInstrument)
+	cfg.respSize = cfg.int64UpDownCounter(meter, respSizeInstrument)
+	cfg.activeReqs = cfg.int64UpDownCounter(meter, activeReqsInstrument)
+	if cfg.QueueTime {
+		cfg.queueTime = cfg.float64Histogram(meter, queueTimeInstrument)
+	}
+	cfg.writeErrors = cfg.int64Counter(meter, writeErrorsInstrument)
+	if cfg.TimeToFirstByte {
+		cfg.ttfb = cfg.float64Histogram(meter, ttfbInstrument)
+	}
+	if cfg.ClientCanceledErrorType != "" {
+		cfg.canceledReqs = cfg.int64Counter(meter, canceledReqsInstrument)
+	}
+	if cfg.SLOThreshold > 0 || len(cfg.RouteSLOThresholds) > 0 {
+		cfg.sloReqs = cfg.int64Counter(meter, sloReqsInstrument)
 	}
+	cfg.bindErrors = cfg.int64Counter(meter, bindErrorsInstrument)
 
-	cfg.activeReqs, err = meter.Int64UpDownCounter("http."+role+".active_requests",
-		otelmetric.WithDescription("Measures the number of messages received per RPC. Should be 1 for all non-streaming RPCs."),
-		otelmetric.WithUnit("{count}"))
-	if err != nil {
-		otel.Handle(err)
-		if cfg.activeReqs == nil {
-			cfg.activeReqs = noop.Int64UpDownCounter{}
-		}
+	if cfg.RecordURLQuery {
+		cfg.query = newQueryRedaction(cfg.RedactedQueryKeys, cfg.QueryRedactor)
+	}
+	if cfg.CardinalityLimit > 0 {
+		cfg.limiter = newCardinalityLimiter(cfg.int64Counter(meter, overflowInstrument), cfg.CardinalityLimit, cfg.CardinalityLimitKeys)
+	}
+	if cfg.DebugStats {
+		cfg.debug = newDebugStats(service, &cfg)
 	}
 
 	return func(c *gin.Context) {
@@ -108,53 +125,193 @@ func Middleware(service string, opts ...Option) gin.HandlerFunc {
 			rAttr       attribute.KeyValue
 		)
 
-		for _, f := range cfg.Filters {
-			if !f(c.Request) {
-				// Serve the request to the next middleware
-				// if a filter rejects the request.
-				c.Next()
-				return
-			}
+		var requestID string
+		if cfg.RequestIDHeader != "" {
+			requestID = cfg.requestID(c)
 		}
-		c.Set(tracerKey, tracer)
-		c.Set(meterKey, meter)
+
 		savedCtx := c.Request.Context()
 		defer func() {
 			c.Request = c.Request.WithContext(savedCtx)
 		}()
-		ctx := cfg.Propagators.Extract(savedCtx, propagation.HeaderCarrier(c.Request.Header))
-		httpTraceAttrs := semconvutil.HTTPServerRequest(service, c.Request)
+		// The deadline applies to every request, filtered or not.
+		if cfg.Timeout > 0 {
+			timeoutCtx, cancel := context.WithTimeout(savedCtx, cfg.Timeout)
+			defer cancel()
+			c.Request = c.Request.WithContext(timeoutCtx)
+		}
+
+		accepted := runFilters(c.Request, cfg.Filters)
+		traced := accepted && runFilters(c.Request, cfg.TraceFilters)
+		metered := accepted && runFilters(c.Request, cfg.MetricFilters)
+		if !traced || !metered {
+			cfg.debug.filter(c.FullPath())
+		}
+		if !traced && !metered {
+			// Serve the request to the next middleware
+			// if the filters reject the request.
+			c.Next()
+			return
+		}
+		ctx := cfg.Propagators.Extract(c.Request.Context(), propagation.HeaderCarrier(c.Request.Header))
+		clientIP := cfg.ClientIPFunc(c)
+		httpTraceAttrs := withClientIP(semconvutil.HTTPServerRequest(service, c.Request), clientIP)
 		opts := []oteltrace.SpanStartOption{
 			oteltrace.WithAttributes(httpTraceAttrs...),
 			oteltrace.WithSpanKind(oteltrace.SpanKindServer),
 		}
+		opts = append(opts, oteltrace.WithAttributes(deadlineAttrs(ctx)...))
+		if requestID != "" {
+			opts = append(opts, oteltrace.WithAttributes(requestIDKey.String(requestID)))
+		}
+		if cfg.RouteParams != nil {
+			opts = append(opts, oteltrace.WithAttributes(cfg.RouteParams.attrs(c.Params)...))
+		}
+		if cfg.query != nil {
+			opts = append(opts, oteltrace.WithAttributes(cfg.query.attrs(c.Request)...))
+		}
 		metricAttrs = semconvutil.HTTPServerRequestMetrics(service, c.Request)
-		var spanName string
-		if cfg.SpanNameFormatter == nil {
-			spanName = c.FullPath()
-		} else {
-			spanName = cfg.SpanNameFormatter(c.Request)
+		if tlsState := c.Request.TLS; tlsState != nil {
+			if cfg.TLSAttributes {
+				opts = append(opts, oteltrace.WithAttributes(tlsSpanAttrs(tlsState)...))
+			}
+			if cfg.TLSMetricAttributes {
+				metricAttrs = append(metricAttrs, tlsMetricAttrs(tlsState)...)
+			}
 		}
-		if spanName == "" {
-			spanName = fmt.Sprintf("HTTP %s route not found", c.Request.Method)
+		var (
+			spanName   string
+			routeAttrs []attribute.KeyValue
+		)
+		if c.FullPath() == "" {
+			// Unmatched requests never reach the SpanNameFormatter,
+			// so that their raw path cannot leak into metrics.
+			spanName, routeAttrs = unmatchedRoute(c, cfg.RouteBucketer)
+			opts = append(opts, oteltrace.WithAttributes(routeAttrs...))
+			metricAttrs = append(metricAttrs, routeAttrs...)
 		} else {
-			rAttr = semconv.HTTPRoute(spanName)
-			opts = append(opts, oteltrace.WithAttributes(rAttr))
-			metricAttrs = append(metricAttrs, rAttr)
+			if cfg.SpanNameFormatter == nil {
+				spanName = c.FullPath()
+			} else {
+				spanName = cfg.SpanNameFormatter(c.Request)
+			}
+			if spanName == "" {
+				spanName = methodName(c.Request.Method)
+			} else {
+				rAttr = semconv.HTTPRoute(spanName)
+				routeAttrs = []attribute.KeyValue{rAttr}
+				opts = append(opts, oteltrace.WithAttributes(rAttr))
+				metricAttrs = append(metricAttrs, rAttr)
+			}
+		}
+		if bag := baggage.FromContext(ctx); bag.Len() > 0 {
+			opts = append(opts, oteltrace.WithAttributes(baggageAttrs(bag, cfg.BaggageSpanKeys)...))
+			metricAttrs = append(metricAttrs, baggageAttrs(bag, cfg.BaggageMetricKeys)...)
 		}
-		ctx, span := tracer.Start(ctx, spanName, opts...)
-		defer span.End()
+		var queueTime float64
+		queued := false
+		if cfg.QueueTime {
+			if start, ok := queueStart(c.Request.Header); ok {
+				if d, ok := queuedFor(start, time.Now()); ok {
+					queued = true
+					queueTime = float64(d) / float64(time.Millisecond)
+					opts = append(opts, oteltrace.WithAttributes(queueTimeKey.Float64(queueTime)))
+					if cfg.QueueTimeSpanStart {
+						opts = append(opts, oteltrace.WithTimestamp(start))
+					}
+				}
+			}
+		}
+		// A request excluded from tracing still goes through the code
+		// below for its metrics, with a non-recording span in place.
+		var span oteltrace.Span = tracenoop.Span{}
+		c.Set(meterKey, meter)
+		c.Set(bindErrorsKey, cfg.bindErrors)
+		if traced {
+			c.Set(tracerKey, tracer)
+			ctx, span = tracer.Start(ctx, spanName, opts...)
+			defer span.End()
 
-		// pass the span through the request context
-		c.Request = c.Request.WithContext(ctx)
+			// pass the span through the request context
+			c.Request = c.Request.WithContext(ctx)
+		}
+		if cfg.ClientCanceledErrorType != "" {
+			defer func() {
+				// http.ErrAbortHandler aborts the response of a request
+				// whose client went away, e.g. in httputil.ReverseProxy.
+				if r := recover(); r != nil {
+					if r == http.ErrAbortHandler {
+						span.SetAttributes(errorTypeKey.String(cfg.ClientCanceledErrorType))
+						if metered {
+							cfg.canceledReqs.Add(ctx, one, otelmetric.WithAttributes(cfg.limiter.limit(ctx, canceledReqsName, metricAttrs)...))
+						}
+					}
+					panic(r)
+				}
+			}()
+		}
 		// calculate the size of the request.
 		reqSize := calcReqSize(c)
 		before := time.Now()
 
+		stopTimeoutWatch := func() {}
+		if traced && cfg.Timeout > 0 {
+			stopTimeoutWatch = watchTimeout(ctx, c, span, cfg.Timeout)
+		}
+
+		if threshold, ok := cfg.slowRequestThreshold(c.FullPath()); ok {
+			// Deferred, so that a request whose handler panics is not
+			// reported once served.
+			defer watchSlowRequest(ctx, &cfg, c, span, threshold)()
+		}
+
+		writer := wrapResponseWriter(c, span, before, cfg.TimeToFirstByte)
+
+		if cfg.InflightRequests {
+			req := InflightRequest{
+				Service:   service,
+				Method:    c.Request.Method,
+				Path:      c.Request.URL.Path,
+				Start:     before,
+				Handler:   c.HandlerName(),
+				ClientIP:  clientIP,
+				RequestID: requestID,
+			}
+			if rAttr.Valid() {
+				req.Route = rAttr.Value.AsString()
+			}
+			if sc := span.SpanContext(); sc.IsValid() {
+				req.TraceID = sc.TraceID().String()
+				req.SpanID = sc.SpanID().String()
+			}
+			defer inflight.add(req)()
+		}
+
 		// serve the request to the next middleware
-		c.Next()
+		if cfg.ProfilerLabels {
+			nextWithLabels(c, profilerLabels(c.Request.Method, routeAttrs, span.SpanContext(), cfg.ProfilerTraceLabels))
+		} else {
+			c.Next()
+		}
+		stopTimeoutWatch()
+		writer.served()
+		if traced {
+			for _, f := range cfg.SpanAttributesFns {
+				span.SetAttributes(f(c)...)
+			}
+		}
+		if metered {
+			for _, f := range cfg.MetricAttributesFns {
+				for _, kv := range f(c) {
+					if _, ok := cfg.MetricAttributesAllowed[kv.Key]; ok {
+						metricAttrs = append(metricAttrs, kv)
+					}
+				}
+			}
+		}
+		elapsed := time.Since(before)
 		// Use floating point division here for higher precision (instead of Millisecond method).
-		elapsedTime := float64(time.Since(before)) / float64(time.Millisecond)
+		elapsedTime := float64(elapsed) / float64(time.Millisecond)
 		respSize := c.Writer.Size()
 		// If nothing written in the response yet, a value of -1 may be returned.
 		if respSize < 0 {
@@ -162,14 +319,51 @@ func Middleware(service string, opts ...Option) gin.HandlerFunc {
 		}
 
 		status := c.Writer.Status()
-		span.SetStatus(semconvutil.HTTPServerStatus(status))
-		cfg.reqSize.Add(ctx, int64(reqSize), otelmetric.WithAttributes(metricAttrs...))
-		cfg.respSize.Add(ctx, int64(respSize), otelmetric.WithAttributes(metricAttrs...))
+		canceled := cfg.ClientCanceledErrorType != "" && clientCanceled(savedCtx)
+		timedOut := !canceled && deadlineExceeded(c)
+		switch {
+		case timedOut:
+			span.SetStatus(codes.Error, "request deadline exceeded")
+		case canceled:
+		case writer.writeErr != nil:
+			span.SetStatus(codes.Error, "response write failed")
+		default:
+			span.SetStatus(cfg.spanStatus(c, status))
+		}
+		if metered {
+			cfg.reqSize.Add(ctx, int64(reqSize), otelmetric.WithAttributes(cfg.limiter.limit(ctx, reqSizeName, metricAttrs)...))
+			cfg.respSize.Add(ctx, int64(respSize), otelmetric.WithAttributes(cfg.limiter.limit(ctx, respSizeName, metricAttrs)...))
+			if queued {
+				cfg.queueTime.Record(ctx, queueTime, otelmetric.WithAttributes(cfg.limiter.limit(ctx, queueTimeName, metricAttrs)...))
+			}
+			if cfg.TimeToFirstByte && !writer.headersSent.IsZero() {
+				cfg.ttfb.Record(ctx, writer.timeToFirstByte(), otelmetric.WithAttributes(cfg.limiter.limit(ctx, ttfbName, metricAttrs)...))
+			}
+		}
 
 		if status > 0 {
 			statusAttr := semconv.HTTPStatusCode(status)
 			span.SetAttributes(statusAttr)
-			metricAttrs = append(metricAttrs, statusAttr)
+			if !canceled {
+				metricAttrs = append(metricAttrs, statusAttr)
+			}
+		}
+		var errorType string
+		switch {
+		case canceled:
+			errorType = cfg.ClientCanceledErrorType
+		case timedOut:
+			errorType = timeoutErrorType
+		default:
+			errorType = cfg.ErrorClassifier(c)
+			if errorType == "" && writer.writeErr != nil {
+				errorType = writeErrorType(writer.writeErr)
+			}
+		}
+		if errorType != "" {
+			errTypeAttr := errorTypeKey.String(errorType)
+			span.SetAttributes(errTypeAttr)
+			metricAttrs = append(metricAttrs, errTypeAttr)
 		}
 		if len(c.Errors) > 0 {
 			errAttr := attribute.String("gin.errors", c.Errors.String())
@@ -179,10 +373,60 @@ func Middleware(service string, opts ...Option) gin.HandlerFunc {
 			}
 
 		}
+		cfg.debug.record(c.FullPath(), elapsed, errorType != "")
+		if !metered {
+			return
+		}
+
+		if writer.writeErr != nil {
+			attrs := append([]attribute.KeyValue{errorTypeKey.String(writeErrorType(writer.writeErr))}, routeAttrs...)
+			cfg.writeErrors.Add(ctx, one, otelmetric.WithAttributes(cfg.limiter.limit(ctx, writeErrorsName, attrs)...))
+		}
+		if canceled {
+			cfg.canceledReqs.Add(ctx, one, otelmetric.WithAttributes(cfg.limiter.limit(ctx, canceledReqsName, metricAttrs)...))
+		}
+		if threshold, ok := cfg.sloThreshold(c.FullPath()); ok && !canceled {
+			attrs := sloAttrs(elapsed, threshold, errorType != "")
+			if rAttr.Valid() {
+				attrs = append(attrs, rAttr)
+			}
+			cfg.sloReqs.Add(ctx, one, otelmetric.WithAttributes(cfg.limiter.limit(ctx, sloReqsName, attrs)...))
+		}
+		cfg.reqDuration.Record(ctx, elapsedTime, otelmetric.WithAttributes(cfg.limiter.limit(ctx, reqDurationName, metricAttrs)...))
+		cfg.activeReqs.Add(ctx, one, otelmetric.WithAttributes(cfg.limiter.limit(ctx, activeReqsName, metricAttrs)...))
+	}
+}
+
+// runFilters reports whether r is accepted by every filter of filters.
+func runFilters(r *http.Request, filters []Filter) bool {
+	for _, f := range filters {
+		if !f(r) {
+			return false
+		}
+	}
+	return true
+}
 
-		cfg.reqDuration.Record(ctx, elapsedTime, otelmetric.WithAttributes(metricAttrs...))
-		cfg.activeReqs.Add(ctx, one, otelmetric.WithAttributes(metricAttrs...))
+// spanStatus returns the span status of c, served with status.
+func (c *config) spanStatus(ctx *gin.Context, status int) (codes.Code, string) {
+	if f, ok := c.RouteSpanStatusFuncs[ctx.FullPath()]; ok && f != nil {
+		return f(ctx, status)
 	}
+	if c.SpanStatusFunc != nil {
+		return c.SpanStatusFunc(ctx, status)
+	}
+	return semconvutil.HTTPServerStatus(status)
+}
+
+// baggageAttrs returns an attribute for each member of bag listed in keys.
+func baggageAttrs(bag baggage.Baggage, keys []string) []attribute.KeyValue {
+	var attrs []attribute.KeyValue
+	for _, k := range keys {
+		if m := bag.Member(k); m.Key() != "" {
+			attrs = append(attrs, attribute.String(k, m.Value()))
+		}
+	}
+	return attrs
 }
 
 // calcReqSize returns the total size of the request.
@@ -217,17 +461,7 @@ func calcReqSize(c *gin.Context) int {
 // gin.Context.HTML function - it invokes the original function after
 // setting up the span.
 func HTML(c *gin.Context, code int, name string, obj interface{}) {
-	var tracer oteltrace.Tracer
-	tracerInterface, ok := c.Get(tracerKey)
-	if ok {
-		tracer, ok = tracerInterface.(oteltrace.Tracer)
-	}
-	if !ok {
-		tracer = otel.GetTracerProvider().Tracer(
-			ScopeName,
-			oteltrace.WithInstrumentationVersion(Version()),
-		)
-	}
+	tracer := tracerFromContext(c)
 	savedContext := c.Request.Context()
 	defer func() {
 		c.Request = c.Request.WithContext(savedContext)
@@ -246,3 +480,31 @@ func HTML(c *gin.Context, code int, name string, obj interface{}) {
 	}()
 	c.HTML(code, name, obj)
 }
+
+// tracerFromContext returns the tracer stored in c by the middleware, or a
+// tracer from the global provider if the request was not traced by it.
+func tracerFromContext(c *gin.Context) oteltrace.Tracer {
+	if v, ok := c.Get(tracerKey); ok {
+		if tracer, ok := v.(oteltrace.Tracer); ok {
+			return tracer
+		}
+	}
+	return otel.GetTracerProvider().Tracer(
+		ScopeName,
+		oteltrace.WithInstrumentationVersion(Version()),
+	)
+}
+
+// meterFromContext returns the meter stored in c by the middleware, or a
+// meter from the global provider if the request was not served by it.
+func meterFromContext(c *gin.Context) otelmetric.Meter {
+	if v, ok := c.Get(meterKey); ok {
+		if meter, ok := v.(otelmetric.Meter); ok {
+			return meter
+		}
+	}
+	return otel.GetMeterProvider().Meter(
+		ScopeName,
+		otelmetric.WithInstrumentationVersion(Version()),
+	)
+}
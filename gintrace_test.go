@@ -18,8 +18,11 @@ import (
 	"github.com/stretchr/testify/assert"
 
 	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
 	"go.opentelemetry.io/otel/propagation"
 	"go.opentelemetry.io/otel/sdk/metric"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
 	"go.opentelemetry.io/otel/trace"
 	"go.opentelemetry.io/otel/trace/noop"
 
@@ -292,3 +295,248 @@ func hasGinErrorsAttr(attrs attribute.Set) bool {
 	}
 	return false
 }
+
+// TestTraceAndMetricFilters tests that trace and metric filters exclude a
+// request from only one signal, while WithFilter excludes it from both.
+func TestTraceAndMetricFilters(t *testing.T) {
+	rejectHealth := func(r *http.Request) bool { return r.URL.Path != "/healthz" }
+	tests := []struct {
+		name         string
+		opt          Option
+		expectSpan   bool
+		expectMetric bool
+	}{
+		{
+			name:         "filter excludes both",
+			opt:          WithFilter(rejectHealth),
+			expectSpan:   false,
+			expectMetric: false,
+		},
+		{
+			name:         "trace filter keeps metrics",
+			opt:          WithTraceFilter(rejectHealth),
+			expectSpan:   false,
+			expectMetric: true,
+		},
+		{
+			name:         "metric filter keeps traces",
+			opt:          WithMetricFilter(rejectHealth),
+			expectSpan:   true,
+			expectMetric: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sr := tracetest.NewSpanRecorder()
+			tracerProvider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(sr))
+			reader := metric.NewManualReader()
+			meterProvider := metric.NewMeterProvider(metric.WithReader(reader))
+			defer func() {
+				_ = meterProvider.Shutdown(context.Background())
+			}()
+
+			router := gin.New()
+			router.Use(Middleware("test-service",
+				WithTracerProvider(tracerProvider),
+				WithMeterProvider(meterProvider),
+				tt.opt,
+			))
+			router.GET("/healthz", func(c *gin.Context) {
+				span := trace.SpanFromContext(c.Request.Context())
+				assert.Equal(t, tt.expectSpan, span.SpanContext().IsValid())
+				c.String(http.StatusOK, "ok")
+			})
+
+			req := httptest.NewRequest("GET", "/healthz", nil)
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+			assert.Equal(t, http.StatusOK, w.Code)
+
+			assert.Equal(t, tt.expectSpan, len(sr.Ended()) == 1)
+
+			var rm metricdata.ResourceMetrics
+			assert.NoError(t, reader.Collect(context.Background(), &rm))
+			assert.Equal(t, tt.expectMetric, len(rm.ScopeMetrics) > 0)
+		})
+	}
+}
+
+// TestFilterRunsOnce tests that a WithFilter filter runs once per request,
+// alongside trace and metric filters.
+func TestFilterRunsOnce(t *testing.T) {
+	var calls int
+	router := gin.New()
+	router.Use(Middleware("test-service",
+		WithFilter(func(*http.Request) bool { calls++; return true }),
+		WithTraceFilter(func(*http.Request) bool { return true }),
+		WithMetricFilter(func(*http.Request) bool { return true }),
+	))
+	router.GET("/user/:id", func(c *gin.Context) {})
+
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/user/123", nil))
+	assert.Equal(t, 1, calls)
+}
+
+// TestAttributesFromContext tests that context attributes are added to the
+// span, and that only allowlisted ones reach the metrics.
+func TestAttributesFromContext(t *testing.T) {
+	sr := tracetest.NewSpanRecorder()
+	tracerProvider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(sr))
+	reader := metric.NewManualReader()
+	meterProvider := metric.NewMeterProvider(metric.WithReader(reader))
+	defer func() {
+		_ = meterProvider.Shutdown(context.Background())
+	}()
+
+	fromContext := func(c *gin.Context) []attribute.KeyValue {
+		return []attribute.KeyValue{
+			attribute.String("enduser.id", c.GetString("user")),
+			attribute.String("tenant.tier", c.GetString("tier")),
+		}
+	}
+	router := gin.New()
+	router.Use(Middleware("test-service",
+		WithTracerProvider(tracerProvider),
+		WithMeterProvider(meterProvider),
+		WithAttributesFromContext(fromContext),
+		WithMetricAttributesFromContext(fromContext, "tenant.tier"),
+	))
+	router.GET("/user", func(c *gin.Context) {
+		c.Set("user", "alice")
+		c.Set("tier", "gold")
+		c.String(http.StatusOK, "ok")
+	})
+
+	req := httptest.NewRequest("GET", "/user", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	spans := sr.Ended()
+	if assert.Len(t, spans, 1) {
+		assert.Contains(t, spans[0].Attributes(), attribute.String("enduser.id", "alice"))
+		assert.Contains(t, spans[0].Attributes(), attribute.String("tenant.tier", "gold"))
+	}
+
+	var rm metricdata.ResourceMetrics
+	assert.NoError(t, reader.Collect(context.Background(), &rm))
+	found := false
+	for _, sm := range rm.ScopeMetrics {
+		for _, m := range sm.Metrics {
+			data, ok := m.Data.(metricdata.Histogram[float64])
+			if !ok {
+				continue
+			}
+			for _, dp := range data.DataPoints {
+				found = true
+				v, ok := dp.Attributes.Value("tenant.tier")
+				assert.True(t, ok)
+				assert.Equal(t, "gold", v.AsString())
+				assert.False(t, dp.Attributes.HasValue("enduser.id"))
+			}
+		}
+	}
+	assert.True(t, found, "Expected a request duration data point")
+}
+
+// TestBaggageAttributes tests that allowlisted baggage members are copied onto
+// the span and the metrics.
+func TestBaggageAttributes(t *testing.T) {
+	sr := tracetest.NewSpanRecorder()
+	tracerProvider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(sr))
+	reader := metric.NewManualReader()
+	meterProvider := metric.NewMeterProvider(metric.WithReader(reader))
+	defer func() {
+		_ = meterProvider.Shutdown(context.Background())
+	}()
+
+	router := gin.New()
+	router.Use(Middleware("test-service",
+		WithTracerProvider(tracerProvider),
+		WithMeterProvider(meterProvider),
+		WithPropagators(propagation.Baggage{}),
+		WithBaggageAttributes("tenant.id", "experiment.bucket", "missing"),
+		WithBaggageMetricAttributes("experiment.bucket"),
+	))
+	router.GET("/ping", func(c *gin.Context) {
+		c.String(http.StatusOK, "ok")
+	})
+
+	req := httptest.NewRequest("GET", "/ping", nil)
+	req.Header.Set("baggage", "tenant.id=acme,experiment.bucket=b,secret=s3cr3t")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	spans := sr.Ended()
+	if assert.Len(t, spans, 1) {
+		attrs := attribute.NewSet(spans[0].Attributes()...)
+		v, _ := attrs.Value("tenant.id")
+		assert.Equal(t, "acme", v.AsString())
+		v, _ = attrs.Value("experiment.bucket")
+		assert.Equal(t, "b", v.AsString())
+		assert.False(t, attrs.HasValue("secret"))
+		assert.False(t, attrs.HasValue("missing"))
+	}
+
+	var rm metricdata.ResourceMetrics
+	assert.NoError(t, reader.Collect(context.Background(), &rm))
+	found := false
+	for _, sm := range rm.ScopeMetrics {
+		for _, m := range sm.Metrics {
+			data, ok := m.Data.(metricdata.Histogram[float64])
+			if !ok {
+				continue
+			}
+			for _, dp := range data.DataPoints {
+				found = true
+				v, _ := dp.Attributes.Value("experiment.bucket")
+				assert.Equal(t, "b", v.AsString())
+				assert.False(t, dp.Attributes.HasValue("tenant.id"))
+			}
+		}
+	}
+	assert.True(t, found, "Expected a request duration data point")
+}
+
+// TestSpanStatusFunc tests that the span status follows the configured
+// function, and that route overrides take precedence over it.
+func TestSpanStatusFunc(t *testing.T) {
+	strict := func(c *gin.Context, status int) (codes.Code, string) {
+		if status == http.StatusTooManyRequests || status >= 500 {
+			return codes.Error, http.StatusText(status)
+		}
+		return codes.Unset, ""
+	}
+	never := func(c *gin.Context, status int) (codes.Code, string) {
+		return codes.Unset, ""
+	}
+
+	sr := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(sr))
+	router := gin.New()
+	router.Use(Middleware("test-service",
+		WithTracerProvider(provider),
+		WithSpanStatusFunc(strict),
+		WithRouteSpanStatusFunc("/poll/:id", never),
+	))
+	router.GET("/limited", func(c *gin.Context) { c.Status(http.StatusTooManyRequests) })
+	router.GET("/missing", func(c *gin.Context) { c.Status(http.StatusNotFound) })
+	router.GET("/poll/:id", func(c *gin.Context) { c.Status(http.StatusGatewayTimeout) })
+
+	tests := []struct {
+		path string
+		want codes.Code
+	}{
+		{"/limited", codes.Error},
+		{"/missing", codes.Unset},
+		{"/poll/1", codes.Unset},
+	}
+	for _, tt := range tests {
+		sr.Reset()
+		router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", tt.path, nil))
+		spans := sr.Ended()
+		if assert.Len(t, spans, 1) {
+			assert.Equal(t, tt.want, spans[0].Status().Code, tt.path)
+		}
+	}
+}
@@ -0,0 +1,96 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package otelgin // import "go.opentelemetry.io/contrib/instrumentation/github.com/gin-gonic/gin/otelgin"
+
+import (
+	"encoding/json"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel"
+)
+
+// InflightRequest describes a request being served by a Middleware created
+// with the WithInflightRequests option.
+type InflightRequest struct {
+	Service string        `json:"service"`
+	Route   string        `json:"route,omitempty"`
+	Method  string        `json:"method"`
+	Path    string        `json:"path"`
+	Start   time.Time     `json:"start"`
+	Elapsed time.Duration `json:"elapsed_ns"`
+	TraceID string        `json:"trace_id,omitempty"`
+	SpanID  string        `json:"span_id,omitempty"`
+	// Handler is the name of the route handler, the last handler of the
+	// chain, which may not be the one running.
+	Handler   string `json:"handler"`
+	ClientIP  string `json:"client_ip,omitempty"`
+	RequestID string `json:"request_id,omitempty"`
+}
+
+// inflightRegistry keeps the requests being served.
+type inflightRegistry struct {
+	mu       sync.Mutex
+	next     uint64
+	requests map[uint64]InflightRequest
+}
+
+// inflight is the registry shared by all the Middleware created with the
+// WithInflightRequests option.
+var inflight = &inflightRegistry{requests: make(map[uint64]InflightRequest)}
+
+// add registers req and returns a function removing it once served.
+func (r *inflightRegistry) add(req InflightRequest) func() {
+	r.mu.Lock()
+	id := r.next
+	r.next++
+	r.requests[id] = req
+	r.mu.Unlock()
+	return func() {
+		r.mu.Lock()
+		delete(r.requests, id)
+		r.mu.Unlock()
+	}
+}
+
+// snapshot returns the requests being served, the oldest first.
+func (r *inflightRegistry) snapshot() []InflightRequest {
+	now := time.Now()
+	r.mu.Lock()
+	requests := make([]InflightRequest, 0, len(r.requests))
+	for _, req := range r.requests {
+		req.Elapsed = now.Sub(req.Start)
+		requests = append(requests, req)
+	}
+	r.mu.Unlock()
+	sort.Slice(requests, func(i, j int) bool {
+		return requests[i].Start.Before(requests[j].Start)
+	})
+	return requests
+}
+
+// InflightRequests returns the requests being served by the Middleware
+// created with the WithInflightRequests option, the oldest first.
+func InflightRequests() []InflightRequest {
+	return inflight.snapshot()
+}
+
+// InflightHandler returns an http.Handler responding with the requests being
+// served by the Middleware created with the WithInflightRequests option, the
+// oldest first, as a JSON object with a "requests" array. It is meant to be
+// mounted on an admin-only route, e.g. with gin.WrapH, to find the requests
+// stuck in a wedged process and their trace IDs.
+func InflightHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		body := struct {
+			Requests []InflightRequest `json:"requests"`
+		}{InflightRequests()}
+		if err := json.NewEncoder(w).Encode(body); err != nil {
+			otel.Handle(err)
+		}
+	})
+}
@@ -0,0 +1,77 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package otelgin
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestInflightRequests(t *testing.T) {
+	sr := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(sr))
+
+	entered := make(chan struct{})
+	release := make(chan struct{})
+	router := gin.New()
+	router.Use(Middleware("test-service",
+		WithTracerProvider(provider),
+		WithInflightRequests(),
+	))
+	router.GET("/user/:id", func(*gin.Context) {
+		close(entered)
+		<-release
+	})
+	router.GET("/inflight", gin.WrapH(InflightHandler()))
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		req := httptest.NewRequest("GET", "/user/123", nil)
+		req.RemoteAddr = "192.0.2.1:1234"
+		router.ServeHTTP(httptest.NewRecorder(), req)
+	}()
+	<-entered
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("GET", "/inflight", nil))
+	close(release)
+	<-done
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "application/json", w.Header().Get("Content-Type"))
+	var body struct {
+		Requests []InflightRequest `json:"requests"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+	// The request to /inflight is itself in flight.
+	require.Len(t, body.Requests, 2)
+	req := body.Requests[0]
+	assert.Equal(t, "test-service", req.Service)
+	assert.Equal(t, "/user/:id", req.Route)
+	assert.Equal(t, "GET", req.Method)
+	assert.Equal(t, "/user/123", req.Path)
+	assert.Equal(t, "192.0.2.1", req.ClientIP)
+	assert.Contains(t, req.Handler, "TestInflightRequests")
+	assert.Positive(t, req.Elapsed)
+	assert.WithinDuration(t, time.Now(), req.Start, time.Minute)
+	assert.Equal(t, "/inflight", body.Requests[1].Route)
+
+	spans := sr.Ended()
+	require.Len(t, spans, 2)
+	assert.Equal(t, spans[1].SpanContext().TraceID().String(), req.TraceID)
+	assert.Equal(t, spans[1].SpanContext().SpanID().String(), req.SpanID)
+
+	assert.Empty(t, InflightRequests(), "served requests are removed")
+}
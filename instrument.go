@@ -0,0 +1,104 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package otelgin // import "go.opentelemetry.io/contrib/instrumentation/github.com/gin-gonic/gin/otelgin"
+
+import (
+	"go.opentelemetry.io/otel"
+	otelmetric "go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/metric/noop"
+)
+
+// instrument describes an instrument recorded by a Middleware.
+type instrument struct {
+	name        string
+	unit        string
+	description string
+}
+
+// The instruments recorded by a Middleware, InstrumentServer and Serve, all
+// created from these definitions, which DebugHandler also reports.
+var (
+	reqDurationInstrument  = instrument{reqDurationName, "ms", "Measures the duration of inbound RPC."}
+	reqSizeInstrument      = instrument{reqSizeName, "By", "Measures size of RPC request messages (uncompressed)."}
+	respSizeInstrument     = instrument{respSizeName, "By", "Measures size of RPC response messages (uncompressed)."}
+	activeReqsInstrument   = instrument{activeReqsName, "{count}", "Measures the number of messages received per RPC. Should be 1 for all non-streaming RPCs."}
+	queueTimeInstrument    = instrument{queueTimeName, "ms", "Measures the time requests spent queued in front of the server."}
+	writeErrorsInstrument  = instrument{writeErrorsName, "{response}", "Counts the responses whose body could not be written."}
+	ttfbInstrument         = instrument{ttfbName, "ms", "Measures the time from the start of requests to the sending of their response headers."}
+	canceledReqsInstrument = instrument{canceledReqsName, "{request}", "Counts the requests canceled by their client before completion."}
+	sloReqsInstrument      = instrument{sloReqsName, "{request}", "Counts the requests by latency SLO outcome and Apdex level."}
+	overflowInstrument     = instrument{overflowName, "{count}", "Counts the metric recordings with an attribute value collapsed by the cardinality limit."}
+	bindErrorsInstrument   = instrument{bindErrorsName, "{count}", "Counts the requests that failed to bind or validate."}
+
+	// Recorded by InstrumentServer and Serve.
+	openConnsInstrument        = instrument{openConnsName, "{connection}", "Measures the number of open connections by state."}
+	connDurationInstrument     = instrument{connDurationName, "ms", "Measures the duration of connections."}
+	connRequestsInstrument     = instrument{connRequestsName, "{request}", "Measures the number of requests served per connection."}
+	shutdownDurationInstrument = instrument{shutdownDurationName, "ms", "Measures the duration of the draining of in-flight requests on shutdown."}
+	abortedReqsInstrument      = instrument{abortedReqsName, "{request}", "Counts the in-flight requests aborted by a shutdown."}
+)
+
+// float64Histogram creates the histogram i with meter, falling back to a
+// no-op one on error, and adds it to the instruments of c.
+func (c *config) float64Histogram(meter otelmetric.Meter, i instrument) otelmetric.Float64Histogram {
+	c.instruments = append(c.instruments, DebugInstrument{i.name, "histogram", i.unit})
+	h, err := meter.Float64Histogram(i.name,
+		otelmetric.WithDescription(i.description),
+		otelmetric.WithUnit(i.unit))
+	if err != nil {
+		otel.Handle(err)
+		if h == nil {
+			h = noop.Float64Histogram{}
+		}
+	}
+	return h
+}
+
+// int64Histogram creates the histogram i with meter, falling back to a no-op
+// one on error, and adds it to the instruments of c.
+func (c *config) int64Histogram(meter otelmetric.Meter, i instrument) otelmetric.Int64Histogram {
+	c.instruments = append(c.instruments, DebugInstrument{i.name, "histogram", i.unit})
+	h, err := meter.Int64Histogram(i.name,
+		otelmetric.WithDescription(i.description),
+		otelmetric.WithUnit(i.unit))
+	if err != nil {
+		otel.Handle(err)
+		if h == nil {
+			h = noop.Int64Histogram{}
+		}
+	}
+	return h
+}
+
+// int64UpDownCounter creates the up-down counter i with meter, falling back
+// to a no-op one on error, and adds it to the instruments of c.
+func (c *config) int64UpDownCounter(meter otelmetric.Meter, i instrument) otelmetric.Int64UpDownCounter {
+	c.instruments = append(c.instruments, DebugInstrument{i.name, "updowncounter", i.unit})
+	u, err := meter.Int64UpDownCounter(i.name,
+		otelmetric.WithDescription(i.description),
+		otelmetric.WithUnit(i.unit))
+	if err != nil {
+		otel.Handle(err)
+		if u == nil {
+			u = noop.Int64UpDownCounter{}
+		}
+	}
+	return u
+}
+
+// int64Counter creates the counter i with meter, falling back to a no-op one
+// on error, and adds it to the instruments of c.
+func (c *config) int64Counter(meter otelmetric.Meter, i instrument) otelmetric.Int64Counter {
+	c.instruments = append(c.instruments, DebugInstrument{i.name, "counter", i.unit})
+	n, err := meter.Int64Counter(i.name,
+		otelmetric.WithDescription(i.description),
+		otelmetric.WithUnit(i.unit))
+	if err != nil {
+		otel.Handle(err)
+		if n == nil {
+			n = noop.Int64Counter{}
+		}
+	}
+	return n
+}
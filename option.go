@@ -7,24 +7,81 @@ package otelgin // import "go.opentelemetry.io/contrib/instrumentation/github.co
 
 import (
 	"net/http"
+	"time"
 
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	otellog "go.opentelemetry.io/otel/log"
 	otelmetric "go.opentelemetry.io/otel/metric"
 	"go.opentelemetry.io/otel/propagation"
 	oteltrace "go.opentelemetry.io/otel/trace"
 )
 
 type config struct {
-	TracerProvider            oteltrace.TracerProvider
-	MeterProvider             otelmetric.MeterProvider
-	Propagators               propagation.TextMapPropagator
-	Filters                   []Filter
-	SpanNameFormatter         SpanNameFormatter
-	DisableGinErrorsOnMetrics bool
+	TracerProvider             oteltrace.TracerProvider
+	MeterProvider              otelmetric.MeterProvider
+	Propagators                propagation.TextMapPropagator
+	Filters                    []Filter
+	TraceFilters               []Filter
+	MetricFilters              []Filter
+	SpanNameFormatter          SpanNameFormatter
+	DisableGinErrorsOnMetrics  bool
+	SpanAttributesFns          []ContextAttributesFunc
+	MetricAttributesFns        []ContextAttributesFunc
+	MetricAttributesAllowed    map[attribute.Key]struct{}
+	BaggageSpanKeys            []string
+	BaggageMetricKeys          []string
+	CardinalityLimit           int
+	CardinalityLimitKeys       []attribute.Key
+	RouteBucketer              RouteBucketer
+	ClientIPFunc               ClientIPFunc
+	RecordURLQuery             bool
+	RedactedQueryKeys          []string
+	QueryRedactor              QueryRedactor
+	RouteParams                *routeParams
+	TLSAttributes              bool
+	TLSMetricAttributes        bool
+	QueueTime                  bool
+	QueueTimeSpanStart         bool
+	ClientCanceledErrorType    string
+	Timeout                    time.Duration
+	ShutdownTimeout            time.Duration
+	ErrorClassifier            ErrorClassifier
+	SpanStatusFunc             SpanStatusFunc
+	RouteSpanStatusFuncs       map[string]SpanStatusFunc
+	SLOThreshold               time.Duration
+	RouteSLOThresholds         map[string]time.Duration
+	LoggerProvider             otellog.LoggerProvider
+	SlowRequestThreshold       time.Duration
+	RouteSlowRequestThresholds map[string]time.Duration
+	SlowRequestAllGoroutines   bool
+	SlowRequestStackSize       int
+	ProfilerLabels             bool
+	ProfilerTraceLabels        bool
+	InflightRequests           bool
+	DebugStats                 bool
+	TimeToFirstByte            bool
+	RequestIDHeader            string
+	RequestIDGenerator         RequestIDGenerator
 
-	reqDuration otelmetric.Float64Histogram
-	reqSize     otelmetric.Int64UpDownCounter
-	respSize    otelmetric.Int64UpDownCounter
-	activeReqs  otelmetric.Int64UpDownCounter
+	reqDuration  otelmetric.Float64Histogram
+	reqSize      otelmetric.Int64UpDownCounter
+	respSize     otelmetric.Int64UpDownCounter
+	activeReqs   otelmetric.Int64UpDownCounter
+	queueTime    otelmetric.Float64Histogram
+	ttfb         otelmetric.Float64Histogram
+	writeErrors  otelmetric.Int64Counter
+	bindErrors   otelmetric.Int64Counter
+	canceledReqs otelmetric.Int64Counter
+	sloReqs      otelmetric.Int64Counter
+
+	logger  otellog.Logger
+	limiter *cardinalityLimiter
+	query   *queryRedaction
+	debug   *debugStats
+
+	instruments []DebugInstrument
 }
 
 // Filter is a predicate used to determine whether a given http.request should
@@ -34,6 +91,14 @@ type Filter func(*http.Request) bool
 // SpanNameFormatter is used to set span name by http.request.
 type SpanNameFormatter func(r *http.Request) string
 
+// ContextAttributesFunc returns attributes derived from a served request, such
+// as values stored in the gin.Context by an authentication middleware.
+type ContextAttributesFunc func(c *gin.Context) []attribute.KeyValue
+
+// SpanStatusFunc returns the span status code and description of a served
+// request from its response status code.
+type SpanStatusFunc func(c *gin.Context, status int) (codes.Code, string)
+
 // Option specifies instrumentation configuration options.
 type Option interface {
 	apply(*config)
@@ -77,9 +142,11 @@ func WithMeterProvider(provider otelmetric.MeterProvider) Option {
 }
 
 // WithFilter adds a filter to the list of filters used by the handler.
-// If any filter indicates to exclude a request then the request will not be
-// traced. All filters must allow a request to be traced for a Span to be created.
-// If no filters are provided then all requests are traced.
+// If any filter indicates to exclude a request then the request will neither
+// be traced nor recorded on metrics. All filters must allow a request to be
+// traced for a Span to be created. If no filters are provided then all requests
+// are traced. Use WithTraceFilter or WithMetricFilter to exclude a request from
+// only one of the two.
 // Filters will be invoked for each processed request, it is advised to make them
 // simple and fast.
 func WithFilter(f ...Filter) Option {
@@ -88,8 +155,30 @@ func WithFilter(f ...Filter) Option {
 	})
 }
 
+// WithTraceFilter adds a filter to the list of filters deciding whether a
+// request is traced. Unlike WithFilter, a request rejected by a trace filter
+// is still recorded on metrics unless a metric filter rejects it as well.
+// This is useful to keep health checks out of traces while still counting
+// them in the request metrics.
+func WithTraceFilter(f ...Filter) Option {
+	return optionFunc(func(c *config) {
+		c.TraceFilters = append(c.TraceFilters, f...)
+	})
+}
+
+// WithMetricFilter adds a filter to the list of filters deciding whether a
+// request is recorded on metrics. Unlike WithFilter, a request rejected by a
+// metric filter is still traced unless a trace filter rejects it as well.
+func WithMetricFilter(f ...Filter) Option {
+	return optionFunc(func(c *config) {
+		c.MetricFilters = append(c.MetricFilters, f...)
+	})
+}
+
 // WithSpanNameFormatter takes a function that will be called on every
-// request and the returned string will become the Span Name.
+// request matching a route and the returned string will become the Span Name.
+// Requests matching no route are named after their method, see
+// WithUnmatchedRouteBucketer.
 func WithSpanNameFormatter(f func(r *http.Request) string) Option {
 	return optionFunc(func(c *config) {
 		c.SpanNameFormatter = f
@@ -98,9 +187,439 @@ func WithSpanNameFormatter(f func(r *http.Request) string) Option {
 
 // WithGinErrorsOnMetrics enables/disables the addition of the label
 // `gin.errors` to metrics. Disabling it helps reduce the
-// number of series, making it easier on metrics systems
+// number of series, making it easier on metrics systems.
+// The bounded `error.type` attribute, see WithErrorClassifier, is a
+// replacement for it.
 func WithDisableGinErrorsOnMetrics(state bool) Option {
 	return optionFunc(func(c *config) {
 		c.DisableGinErrorsOnMetrics = state
 	})
 }
+
+// WithAttributesFromContext adds a function that is called once the request
+// has been served by the rest of the handler chain. The returned attributes are
+// set on the server span, e.g. an `enduser.id` read with c.Get from a value
+// stored by an authentication middleware. They are never added to metrics, see
+// WithMetricAttributesFromContext for that.
+func WithAttributesFromContext(f ContextAttributesFunc) Option {
+	return optionFunc(func(c *config) {
+		if f != nil {
+			c.SpanAttributesFns = append(c.SpanAttributesFns, f)
+		}
+	})
+}
+
+// WithMetricAttributesFromContext adds a function that is called once the
+// request has been served by the rest of the handler chain. Only the returned
+// attributes whose key is in allowed are added to the metrics, which keeps
+// high-cardinality values such as user IDs from reaching them by mistake.
+// Allowed keys accumulate across calls.
+func WithMetricAttributesFromContext(f ContextAttributesFunc, allowed ...attribute.Key) Option {
+	return optionFunc(func(c *config) {
+		if f == nil {
+			return
+		}
+		c.MetricAttributesFns = append(c.MetricAttributesFns, f)
+		if c.MetricAttributesAllowed == nil {
+			c.MetricAttributesAllowed = make(map[attribute.Key]struct{}, len(allowed))
+		}
+		for _, k := range allowed {
+			c.MetricAttributesAllowed[k] = struct{}{}
+		}
+	})
+}
+
+// WithBaggageAttributes specifies baggage members that are copied onto the
+// server span as attributes, keyed by the member key. The baggage is read from
+// the request through the configured propagators, so these must include a
+// baggage propagator. Members not present in the request are skipped.
+func WithBaggageAttributes(keys ...string) Option {
+	return optionFunc(func(c *config) {
+		c.BaggageSpanKeys = append(c.BaggageSpanKeys, keys...)
+	})
+}
+
+// WithBaggageMetricAttributes specifies baggage members that are copied onto
+// the metric attributes, keyed by the member key. It should be kept stricter
+// than WithBaggageAttributes: every member listed here becomes a metric
+// dimension, so only low-cardinality members belong to it.
+func WithBaggageMetricAttributes(keys ...string) Option {
+	return optionFunc(func(c *config) {
+		c.BaggageMetricKeys = append(c.BaggageMetricKeys, keys...)
+	})
+}
+
+// WithMetricCardinalityLimit caps the number of distinct values each of the
+// given metric attributes can take per instrument. Once limit distinct values
+// have been seen, new values are recorded as "_OTHER" and counted by the
+// otelgin.metric.attribute.overflow counter. If no keys are given, the limit
+// applies to `http.route`, `gin.errors` and `error.type`. A limit of zero or
+// less disables the guard, which is the default.
+func WithMetricCardinalityLimit(limit int, keys ...attribute.Key) Option {
+	return optionFunc(func(c *config) {
+		c.CardinalityLimit = limit
+		c.CardinalityLimitKeys = keys
+	})
+}
+
+// WithUnmatchedRouteBucketer specifies a function grouping the requests that
+// match no route, including the ones answered by engine.NoRoute and
+// engine.NoMethod handlers. Such requests are named after their method, e.g.
+// "GET", and carry a `gin.route.unmatched` attribute telling a 404 from a 405.
+// When the bucketer returns a bucket for the request path, the bucket is
+// appended to the span name and recorded as the `gin.route.bucket` attribute
+// on both spans and metrics.
+func WithUnmatchedRouteBucketer(f RouteBucketer) Option {
+	return optionFunc(func(c *config) {
+		c.RouteBucketer = f
+	})
+}
+
+// WithClientIPFunc specifies a function returning the address recorded as
+// `http.client_ip` on the server span. By default it is gin.Context.ClientIP,
+// which trusts the X-Forwarded-For and X-Real-IP headers sent by the proxies
+// configured with engine.SetTrustedProxies. As a gin.Engine trusts every proxy
+// until then, engine.SetTrustedProxies must be called for the default to be
+// trustworthy; otherwise the address is the first X-Forwarded-For entry,
+// which any client can spoof. Use WithTrustedProxies to honor the RFC 7239
+// Forwarded header.
+func WithClientIPFunc(f ClientIPFunc) Option {
+	return optionFunc(func(c *config) {
+		c.ClientIPFunc = f
+	})
+}
+
+// WithTrustedProxies makes the middleware derive `http.client_ip` from its own
+// policy instead of the gin.Engine configuration. The proxies are IP addresses
+// or CIDR ranges. The RFC 7239 Forwarded header, or else the X-Forwarded-For
+// header, is walked backwards from the peer of the connection, and the first
+// address that is not a trusted proxy is the client. Calling it with no
+// proxies trusts none, so the client is always the peer of the connection.
+func WithTrustedProxies(proxies ...string) Option {
+	return optionFunc(func(c *config) {
+		c.ClientIPFunc = newTrustedProxies(proxies).clientIP
+	})
+}
+
+// WithURLQuery enables/disables the addition of the `url.query` and `url.full`
+// attributes to the server span. The values of the `token`, `sig`,
+// `X-Amz-Signature` and `password` parameters, and of the ones given to
+// WithRedactedQueryKeys, are replaced with "REDACTED". The query string is
+// never added to metrics.
+func WithURLQuery(state bool) Option {
+	return optionFunc(func(c *config) {
+		c.RecordURLQuery = state
+	})
+}
+
+// WithRedactedQueryKeys adds query parameters whose value is redacted when
+// the query string is recorded, see WithURLQuery. Keys are matched without
+// regard to case.
+func WithRedactedQueryKeys(keys ...string) Option {
+	return optionFunc(func(c *config) {
+		c.RedactedQueryKeys = append(c.RedactedQueryKeys, keys...)
+	})
+}
+
+// WithQueryRedactor specifies a function called for each query parameter that
+// is not redacted by its key when the query string is recorded, see
+// WithURLQuery. It allows custom redaction, e.g. of values that look like
+// e-mail addresses.
+func WithQueryRedactor(f QueryRedactor) Option {
+	return optionFunc(func(c *config) {
+		c.QueryRedactor = f
+	})
+}
+
+// WithRouteParamAttributes specifies route parameters recorded on the server
+// span as `http.route.param.<name>` attributes, e.g. "org" and "repo" for the
+// "/orgs/:org/repos/:repo" route. Their values are recorded in full, with no
+// length cap. Route parameters are never added to metrics.
+func WithRouteParamAttributes(names ...string) Option {
+	return optionFunc(func(c *config) {
+		if c.RouteParams == nil || c.RouteParams.names == nil {
+			c.RouteParams = &routeParams{names: make(map[string]struct{}, len(names))}
+		}
+		for _, n := range names {
+			c.RouteParams.names[n] = struct{}{}
+		}
+	})
+}
+
+// WithAllRouteParamAttributes records every route parameter on the server
+// span as a `http.route.param.<name>` attribute, see WithRouteParamAttributes.
+// Values longer than maxLen bytes are truncated on a UTF-8 character
+// boundary, unless maxLen is zero or less.
+func WithAllRouteParamAttributes(maxLen int) Option {
+	return optionFunc(func(c *config) {
+		c.RouteParams = &routeParams{maxLen: maxLen}
+	})
+}
+
+// WithTLSAttributes enables/disables the addition of attributes describing the
+// TLS connection to the server span: `tls.protocol.version`, `tls.cipher`,
+// `tls.resumed`, `tls.server_name` and, when the client presented a
+// certificate, `tls.client.subject`, `tls.client.issuer`, `tls.client.serial`
+// and `tls.client.not_after`.
+func WithTLSAttributes(state bool) Option {
+	return optionFunc(func(c *config) {
+		c.TLSAttributes = state
+	})
+}
+
+// WithTLSMetricAttributes enables/disables the addition of the
+// `tls.protocol.version` attribute to metrics, e.g. to track the use of
+// deprecated TLS versions per route.
+func WithTLSMetricAttributes(state bool) Option {
+	return optionFunc(func(c *config) {
+		c.TLSMetricAttributes = state
+	})
+}
+
+// WithQueueTime enables/disables the measurement of the time a request spent
+// queued in front of the server, from the X-Request-Start or X-Queue-Start
+// header set by a load balancer such as nginx or the Heroku router. It is
+// recorded by the http.server.request.queue_time histogram and as the
+// `http.request.queue_time` span attribute, both in milliseconds. As these
+// headers can be set by any client, a time in the future or more than 5
+// minutes ago is ignored.
+func WithQueueTime(state bool) Option {
+	return optionFunc(func(c *config) {
+		c.QueueTime = state
+	})
+}
+
+// WithQueueTimeSpanStart enables/disables starting the server span at the
+// time the request was received by the load balancer, so that the queue time
+// is part of the span. It implies WithQueueTime(true) when enabled.
+func WithQueueTimeSpanStart(state bool) Option {
+	return optionFunc(func(c *config) {
+		c.QueueTimeSpanStart = state
+		if state {
+			c.QueueTime = true
+		}
+	})
+}
+
+// WithClientCanceledErrorType specifies the `error.type` recorded for requests
+// canceled by their client, which are detected from the cancellation of the
+// request context or a http.ErrAbortHandler panic. Such requests are not
+// marked as errors on the span and are recorded on metrics with this
+// `error.type` instead of their status code, so they stay out of 5xx error
+// rates, and are counted by the http.server.request.canceled counter. The
+// default is "client_canceled", an empty string disables the detection.
+func WithClientCanceledErrorType(errorType string) Option {
+	return optionFunc(func(c *config) {
+		c.ClientCanceledErrorType = errorType
+	})
+}
+
+// WithTimeout sets a deadline of timeout on the context of every request,
+// including the ones rejected by the filters, to be honored by the handlers.
+// If the deadline is exceeded while the request is still being served, a
+// "timeout" event naming the route handler is added to the server span, and
+// the request is recorded with `error.type=timeout`. It does not write any
+// response on its own, so it can be combined with a middleware such as
+// gin-contrib/timeout answering the client.
+func WithTimeout(timeout time.Duration) Option {
+	return optionFunc(func(c *config) {
+		c.Timeout = timeout
+	})
+}
+
+// WithShutdownTimeout specifies how long Serve drains in-flight requests on
+// shutdown before aborting them. It is ignored by Middleware.
+func WithShutdownTimeout(timeout time.Duration) Option {
+	return optionFunc(func(c *config) {
+		if timeout > 0 {
+			c.ShutdownTimeout = timeout
+		}
+	})
+}
+
+// WithErrorClassifier specifies a function mapping the outcome of a served
+// request to the `error.type` recorded on the span and the metrics. It should
+// return a small, bounded set of categories, or an empty string if the
+// request did not fail. By default, 5xx responses are classified by their
+// status code and other requests with errors attached to the gin.Context by
+// the Go type of the last error. Requests canceled by their client or that
+// exceeded their deadline are classified before the classifier is called.
+func WithErrorClassifier(f ErrorClassifier) Option {
+	return optionFunc(func(c *config) {
+		c.ErrorClassifier = f
+	})
+}
+
+// WithSpanStatusFunc specifies a function setting the status of the server
+// span from the response status code. By default, only 5xx responses are
+// errors, e.g. a function can also mark 429 responses as errors. It is not
+// called for requests canceled by their client or that exceeded their
+// deadline.
+func WithSpanStatusFunc(f SpanStatusFunc) Option {
+	return optionFunc(func(c *config) {
+		c.SpanStatusFunc = f
+	})
+}
+
+// WithRouteSpanStatusFunc specifies a function setting the status of the
+// server span for the requests matching route, e.g. "/poll/:id", overriding
+// the one given with WithSpanStatusFunc. This allows a long-polling route
+// returning 504 by design to never be marked as an error.
+func WithRouteSpanStatusFunc(route string, f SpanStatusFunc) Option {
+	return optionFunc(func(c *config) {
+		if c.RouteSpanStatusFuncs == nil {
+			c.RouteSpanStatusFuncs = make(map[string]SpanStatusFunc)
+		}
+		c.RouteSpanStatusFuncs[route] = f
+	})
+}
+
+// WithSLO specifies the latency threshold of the requests matching a route,
+// unless overridden with WithRouteSLO. Each such request is counted by the
+// http.server.slo.requests counter with a `slo.outcome` attribute, "good"
+// within threshold, "slow" beyond it or "error" if it failed with an
+// `error.type`, and an `apdex.level` attribute, "satisfied" within threshold,
+// "tolerating" within 4 times threshold and "frustrated" beyond it or if it
+// failed. Requests canceled by their client are not counted.
+func WithSLO(threshold time.Duration) Option {
+	return optionFunc(func(c *config) {
+		c.SLOThreshold = threshold
+	})
+}
+
+// WithRouteSLO specifies the latency threshold of the requests matching
+// route, e.g. "/users/:id", see WithSLO. A threshold of zero or less disables
+// the SLO for route.
+func WithRouteSLO(route string, threshold time.Duration) Option {
+	return optionFunc(func(c *config) {
+		if c.RouteSLOThresholds == nil {
+			c.RouteSLOThresholds = make(map[string]time.Duration)
+		}
+		c.RouteSLOThresholds[route] = threshold
+	})
+}
+
+// WithLoggerProvider specifies a logger provider to use for emitting log
+// records, e.g. about slow requests. If none is specified, the global provider
+// is used.
+func WithLoggerProvider(provider otellog.LoggerProvider) Option {
+	return optionFunc(func(cfg *config) {
+		if provider != nil {
+			cfg.LoggerProvider = provider
+		}
+	})
+}
+
+// WithSlowRequestThreshold specifies how long the requests matching a route
+// can be served before being reported as slow, unless overridden with
+// WithRouteSlowRequestThreshold. A request still being served past its
+// threshold gets a "slow_request" span event and a log record carrying the
+// name of the route handler and the stack trace of the goroutine serving it,
+// to tell where the request is stuck. Watching a request costs a timer and a
+// call to runtime.Stack to identify its goroutine. Reporting it takes a dump
+// of the stacks of all goroutines, which stops the world: a dump is shared by
+// the requests going slow within a second of each other and is capped to
+// 16 MiB.
+func WithSlowRequestThreshold(threshold time.Duration) Option {
+	return optionFunc(func(c *config) {
+		c.SlowRequestThreshold = threshold
+	})
+}
+
+// WithRouteSlowRequestThreshold specifies how long the requests matching
+// route, e.g. "/users/:id", can be served before being reported as slow, see
+// WithSlowRequestThreshold.
+func WithRouteSlowRequestThreshold(route string, threshold time.Duration) Option {
+	return optionFunc(func(c *config) {
+		if c.RouteSlowRequestThresholds == nil {
+			c.RouteSlowRequestThresholds = make(map[string]time.Duration)
+		}
+		c.RouteSlowRequestThresholds[route] = threshold
+	})
+}
+
+// WithSlowRequestStack specifies the stack trace reported for slow requests:
+// the stack traces of all goroutines if all is true, instead of only the one
+// of the goroutine serving the request, capped to size bytes, 64 KiB if size
+// is zero or less.
+func WithSlowRequestStack(all bool, size int) Option {
+	return optionFunc(func(c *config) {
+		c.SlowRequestAllGoroutines = all
+		if size > 0 {
+			c.SlowRequestStackSize = size
+		}
+	})
+}
+
+// WithProfilerLabels specifies that the requests are served with pprof labels
+// set, so that CPU and goroutine profiles can be filtered by endpoint: an
+// `http.route` label, or `gin.route.unmatched` and `gin.route.bucket` labels
+// for the requests matching no route, and an `http.request.method` label. If
+// traceIDs is true, the `trace_id` and `span_id` labels of the server span are
+// also set, so that continuous profilers can link profiles to traces. Labels
+// are inherited by the goroutines started by the handlers, and are found in
+// the request context with pprof.Label.
+func WithProfilerLabels(traceIDs bool) Option {
+	return optionFunc(func(c *config) {
+		c.ProfilerLabels = true
+		c.ProfilerTraceLabels = traceIDs
+	})
+}
+
+// WithInflightRequests specifies that the requests being served are kept in a
+// registry, along with their route, method, start time, trace ID, route
+// handler and client address, to be reported by InflightRequests and
+// InflightHandler.
+func WithInflightRequests() Option {
+	return optionFunc(func(c *config) {
+		c.InflightRequests = true
+	})
+}
+
+// WithDebugStats specifies that the effective configuration of the
+// Middleware, the names and units of its instruments and in-process
+// statistics by route template, the number of requests served, failed with
+// an `error.type` or rejected by the filters and the estimated median and 99th
+// percentile of their duration, are reported by DebugReports and
+// DebugHandler. The Middleware stays registered for the life of the process,
+// so the option is meant for Middleware created once, at startup.
+func WithDebugStats() Option {
+	return optionFunc(func(c *config) {
+		c.DebugStats = true
+	})
+}
+
+// WithTimeToFirstByte specifies that the time from the start of requests to
+// the sending of their response headers, by the first write or flush, is
+// recorded by the http.server.time_to_first_byte histogram and a
+// "response.headers_sent" span event, to tell a response slow to start from
+// one slow to stream. The headers of a response with no body are sent once
+// the request is served.
+func WithTimeToFirstByte() Option {
+	return optionFunc(func(c *config) {
+		c.TimeToFirstByte = true
+	})
+}
+
+// WithRequestID specifies that the requests are given an ID, read from the
+// header, "X-Request-ID" if empty, or generated as a random UUID if the
+// header is missing or invalid. The ID is recorded on the server span as the
+// `http.request.id` attribute, stored in the gin.Context to be read with
+// RequestID, and echoed on the response in the same header, including for the
+// requests rejected by the filters.
+func WithRequestID(header string) Option {
+	return optionFunc(func(c *config) {
+		if header == "" {
+			header = defaultRequestIDHeader
+		}
+		c.RequestIDHeader = header
+	})
+}
+
+// WithRequestIDGenerator specifies a function generating the ID of the
+// requests coming without a valid one, when enabled with WithRequestID.
+func WithRequestIDGenerator(gen RequestIDGenerator) Option {
+	return optionFunc(func(c *config) {
+		c.RequestIDGenerator = gen
+	})
+}
@@ -0,0 +1,49 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package otelgin // import "go.opentelemetry.io/contrib/instrumentation/github.com/gin-gonic/gin/otelgin"
+
+import (
+	"unicode/utf8"
+
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// routeParamKeyPrefix prefixes the name of a route parameter in its span
+// attribute key, e.g. "http.route.param.org" for "/orgs/:org".
+const routeParamKeyPrefix = "http.route.param."
+
+// routeParams selects the route parameters recorded on the server span.
+type routeParams struct {
+	// names are the recorded parameters. If nil, all of them are.
+	names map[string]struct{}
+	// maxLen truncates the recorded values if greater than zero.
+	maxLen int
+}
+
+func (p *routeParams) attrs(params gin.Params) []attribute.KeyValue {
+	var attrs []attribute.KeyValue
+	for _, param := range params {
+		if p.names != nil {
+			if _, ok := p.names[param.Key]; !ok {
+				continue
+			}
+		}
+		attrs = append(attrs, attribute.String(routeParamKeyPrefix+param.Key, truncate(param.Value, p.maxLen)))
+	}
+	return attrs
+}
+
+// truncate returns v cut to at most maxLen bytes on a rune boundary, or v as
+// is if maxLen is zero or less.
+func truncate(v string, maxLen int) string {
+	if maxLen <= 0 || len(v) <= maxLen {
+		return v
+	}
+	i := maxLen
+	for i > 0 && !utf8.RuneStart(v[i]) {
+		i--
+	}
+	return v[:i]
+}
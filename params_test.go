@@ -0,0 +1,108 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package otelgin
+
+import (
+	"context"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestRouteParamAttributes(t *testing.T) {
+	tests := []struct {
+		name    string
+		opt     Option
+		want    map[attribute.Key]string
+		notWant []attribute.Key
+	}{
+		{
+			name:    "allowlist",
+			opt:     WithRouteParamAttributes("org"),
+			want:    map[attribute.Key]string{"http.route.param.org": "acme"},
+			notWant: []attribute.Key{"http.route.param.repo"},
+		},
+		{
+			name: "capture all with length cap",
+			opt:  WithAllRouteParamAttributes(4),
+			want: map[attribute.Key]string{
+				"http.route.param.org":  "acme",
+				"http.route.param.repo": "otel",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sr := tracetest.NewSpanRecorder()
+			tracerProvider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(sr))
+			reader := metric.NewManualReader()
+			meterProvider := metric.NewMeterProvider(metric.WithReader(reader))
+			defer func() {
+				_ = meterProvider.Shutdown(context.Background())
+			}()
+
+			router := gin.New()
+			router.Use(Middleware("test-service",
+				WithTracerProvider(tracerProvider),
+				WithMeterProvider(meterProvider),
+				tt.opt,
+			))
+			router.GET("/orgs/:org/repos/:repo", func(c *gin.Context) {})
+			router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/orgs/acme/repos/otelgin", nil))
+
+			spans := sr.Ended()
+			require.Len(t, spans, 1)
+			attrs := attribute.NewSet(spans[0].Attributes()...)
+			for k, want := range tt.want {
+				v, ok := attrs.Value(k)
+				assert.True(t, ok, "missing attribute %s", k)
+				assert.Equal(t, want, v.AsString())
+			}
+			for _, k := range tt.notWant {
+				assert.False(t, attrs.HasValue(k), "unexpected attribute %s", k)
+			}
+
+			var rm metricdata.ResourceMetrics
+			require.NoError(t, reader.Collect(context.Background(), &rm))
+			for _, sm := range rm.ScopeMetrics {
+				for _, m := range sm.Metrics {
+					data, ok := m.Data.(metricdata.Histogram[float64])
+					if !ok {
+						continue
+					}
+					for _, dp := range data.DataPoints {
+						for _, kv := range dp.Attributes.ToSlice() {
+							assert.False(t, strings.HasPrefix(string(kv.Key), routeParamKeyPrefix))
+						}
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestRouteParamTruncate(t *testing.T) {
+	p := &routeParams{maxLen: 4}
+	// "é" is 2 bytes: the 4-byte cut falls in the middle of the second one.
+	attrs := p.attrs(gin.Params{{Key: "name", Value: "aéé"}})
+	require.Len(t, attrs, 1)
+	assert.Equal(t, "aé", attrs[0].Value.AsString())
+	assert.True(t, utf8.ValidString(attrs[0].Value.AsString()))
+
+	assert.Equal(t, "日本", truncate("日本語", 8))
+	assert.Equal(t, "abc", truncate("abc", 3))
+	assert.Equal(t, "abcdef", truncate("abcdef", 0))
+}
@@ -0,0 +1,47 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package otelgin // import "go.opentelemetry.io/contrib/instrumentation/github.com/gin-gonic/gin/otelgin"
+
+import (
+	"context"
+	"runtime/pprof"
+
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/otel/attribute"
+	oteltrace "go.opentelemetry.io/otel/trace"
+)
+
+const (
+	methodLabel  = "http.request.method"
+	traceIDLabel = "trace_id"
+	spanIDLabel  = "span_id"
+)
+
+// profilerLabels returns the pprof labels of a request served with method,
+// the route attributes and the span context sc, including its trace and
+// span IDs if traceIDs is true.
+func profilerLabels(method string, route []attribute.KeyValue, sc oteltrace.SpanContext, traceIDs bool) pprof.LabelSet {
+	labels := make([]string, 0, 2*(len(route)+3))
+	labels = append(labels, methodLabel, methodName(method))
+	for _, kv := range route {
+		labels = append(labels, string(kv.Key), kv.Value.Emit())
+	}
+	if traceIDs && sc.IsValid() {
+		labels = append(labels,
+			traceIDLabel, sc.TraceID().String(),
+			spanIDLabel, sc.SpanID().String(),
+		)
+	}
+	return pprof.Labels(labels...)
+}
+
+// nextWithLabels serves the request to the next middleware with the pprof
+// labels set on the serving goroutine, and on the request context so that
+// handlers can look them up or apply them to other goroutines.
+func nextWithLabels(c *gin.Context, labels pprof.LabelSet) {
+	pprof.Do(c.Request.Context(), labels, func(ctx context.Context) {
+		c.Request = c.Request.WithContext(ctx)
+		c.Next()
+	})
+}
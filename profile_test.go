@@ -0,0 +1,79 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package otelgin
+
+import (
+	"context"
+	"net/http/httptest"
+	"runtime/pprof"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	oteltrace "go.opentelemetry.io/otel/trace"
+)
+
+func TestProfilerLabels(t *testing.T) {
+	sr := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(sr))
+
+	labels := map[string]string{}
+	router := gin.New()
+	router.Use(Middleware("test-service",
+		WithTracerProvider(provider),
+		WithProfilerLabels(true),
+	))
+	router.GET("/user/:id", func(c *gin.Context) {
+		pprof.ForLabels(c.Request.Context(), func(key, value string) bool {
+			labels[key] = value
+			return true
+		})
+	})
+
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/user/123", nil))
+
+	spans := sr.Ended()
+	require.Len(t, spans, 1)
+	sc := spans[0].SpanContext()
+	assert.Equal(t, map[string]string{
+		"http.route":          "/user/:id",
+		"http.request.method": "GET",
+		"trace_id":            sc.TraceID().String(),
+		"span_id":             sc.SpanID().String(),
+	}, labels)
+}
+
+func TestProfilerLabelsUnmatched(t *testing.T) {
+	labels := map[string]string{}
+	router := gin.New()
+	router.Use(Middleware("test-service", WithProfilerLabels(false)))
+	router.NoRoute(func(c *gin.Context) {
+		pprof.ForLabels(c.Request.Context(), func(key, value string) bool {
+			labels[key] = value
+			return true
+		})
+	})
+
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("POST", "/missing", nil))
+
+	assert.Equal(t, map[string]string{
+		"gin.route.unmatched": "not_found",
+		"http.request.method": "POST",
+	}, labels)
+}
+
+func TestProfilerLabelsWithoutSpan(t *testing.T) {
+	labels := profilerLabels("BREW", nil, oteltrace.SpanContext{}, true)
+	got := map[string]string{}
+	ctx := pprof.WithLabels(context.Background(), labels)
+	pprof.ForLabels(ctx, func(key, value string) bool {
+		got[key] = value
+		return true
+	})
+	assert.Equal(t, map[string]string{"http.request.method": "HTTP"}, got)
+}
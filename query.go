@@ -0,0 +1,91 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package otelgin // import "go.opentelemetry.io/contrib/instrumentation/github.com/gin-gonic/gin/otelgin"
+
+import (
+	"net/http"
+	"net/url"
+	"strings"
+
+	"go.opentelemetry.io/otel/attribute"
+)
+
+const (
+	urlQueryKey = attribute.Key("url.query")
+	urlFullKey  = attribute.Key("url.full")
+
+	// redactedValue replaces the value of a sensitive query parameter.
+	redactedValue = "REDACTED"
+)
+
+// defaultRedactedQueryKeys are the query parameters whose value is always
+// redacted when the query is recorded.
+var defaultRedactedQueryKeys = []string{"token", "sig", "X-Amz-Signature", "password"}
+
+// QueryRedactor returns the value to record for the query parameter key,
+// e.g. value itself or "REDACTED". key and value are unescaped.
+type QueryRedactor func(key, value string) string
+
+// queryRedaction redacts the query string of requests before it is recorded.
+type queryRedaction struct {
+	keys     map[string]struct{}
+	redactor QueryRedactor
+}
+
+func newQueryRedaction(keys []string, redactor QueryRedactor) *queryRedaction {
+	q := &queryRedaction{
+		keys:     make(map[string]struct{}, len(defaultRedactedQueryKeys)+len(keys)),
+		redactor: redactor,
+	}
+	for _, k := range defaultRedactedQueryKeys {
+		q.keys[strings.ToLower(k)] = struct{}{}
+	}
+	for _, k := range keys {
+		q.keys[strings.ToLower(k)] = struct{}{}
+	}
+	return q
+}
+
+// redact returns rawQuery with the value of every sensitive parameter
+// replaced. The order and escaping of the other parameters are kept.
+func (q *queryRedaction) redact(rawQuery string) string {
+	pairs := strings.Split(rawQuery, "&")
+	for i, pair := range pairs {
+		rawKey, rawValue, _ := strings.Cut(pair, "=")
+		key, err := url.QueryUnescape(rawKey)
+		if err != nil {
+			key = rawKey
+		}
+		if _, ok := q.keys[strings.ToLower(key)]; ok {
+			pairs[i] = rawKey + "=" + redactedValue
+			continue
+		}
+		if q.redactor == nil {
+			continue
+		}
+		value, err := url.QueryUnescape(rawValue)
+		if err != nil {
+			value = rawValue
+		}
+		if redacted := q.redactor(key, value); redacted != value {
+			pairs[i] = rawKey + "=" + url.QueryEscape(redacted)
+		}
+	}
+	return strings.Join(pairs, "&")
+}
+
+// attrs returns the url.query and url.full attributes of r. It returns none
+// if r has no query string.
+func (q *queryRedaction) attrs(r *http.Request) []attribute.KeyValue {
+	if r.URL == nil || r.URL.RawQuery == "" {
+		return nil
+	}
+	query := q.redact(r.URL.RawQuery)
+	scheme := "http"
+	if r.TLS != nil {
+		scheme = "https"
+	}
+	full := scheme + "://" + r.Host + r.URL.EscapedPath() + "?" + query
+	return []attribute.KeyValue{urlQueryKey.String(query), urlFullKey.String(full)}
+}
@@ -0,0 +1,92 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package otelgin
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"go.opentelemetry.io/otel/attribute"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestQueryRedaction(t *testing.T) {
+	emails := func(key, value string) string {
+		if strings.Contains(value, "@") {
+			return redactedValue
+		}
+		return value
+	}
+	q := newQueryRedaction([]string{"session"}, emails)
+
+	tests := []struct {
+		rawQuery string
+		want     string
+	}{
+		{"page=2&limit=10", "page=2&limit=10"},
+		{"token=abc&page=2", "token=REDACTED&page=2"},
+		{"x-amz-signature=abc&X-Amz-Date=20240101", "x-amz-signature=REDACTED&X-Amz-Date=20240101"},
+		{"session=abc&password=hunter2", "session=REDACTED&password=REDACTED"},
+		{"q=caf%C3%A9&user=a%40b.com", "q=caf%C3%A9&user=REDACTED"},
+		{"flag&sig=", "flag&sig=REDACTED"},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, q.redact(tt.rawQuery), tt.rawQuery)
+	}
+}
+
+func TestURLQuery(t *testing.T) {
+	tests := []struct {
+		name        string
+		opts        []Option
+		target      string
+		expectQuery string
+		expectFull  string
+	}{
+		{
+			name:   "disabled by default",
+			target: "/items?token=abc&page=2",
+		},
+		{
+			name:        "enabled",
+			opts:        []Option{WithURLQuery(true)},
+			target:      "/items?token=abc&page=2",
+			expectQuery: "token=REDACTED&page=2",
+			expectFull:  "http://example.com/items?token=REDACTED&page=2",
+		},
+		{
+			name:   "no query",
+			opts:   []Option{WithURLQuery(true)},
+			target: "/items",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sr := tracetest.NewSpanRecorder()
+			provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(sr))
+
+			router := gin.New()
+			router.Use(Middleware("test-service", append(tt.opts, WithTracerProvider(provider))...))
+			router.GET("/items", func(c *gin.Context) {})
+			router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", tt.target, nil))
+
+			spans := sr.Ended()
+			require.Len(t, spans, 1)
+			attrs := attribute.NewSet(spans[0].Attributes()...)
+			query, ok := attrs.Value(urlQueryKey)
+			assert.Equal(t, tt.expectQuery != "", ok)
+			assert.Equal(t, tt.expectQuery, query.AsString())
+			full, ok := attrs.Value(urlFullKey)
+			assert.Equal(t, tt.expectFull != "", ok)
+			assert.Equal(t, tt.expectFull, full.AsString())
+		})
+	}
+}
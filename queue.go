@@ -0,0 +1,70 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package otelgin // import "go.opentelemetry.io/contrib/instrumentation/github.com/gin-gonic/gin/otelgin"
+
+import (
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+)
+
+const (
+	queueTimeName = "http." + role + ".request.queue_time"
+	queueTimeKey  = attribute.Key("http.request.queue_time")
+
+	// maxQueueTime bounds the queue time taken from the request headers,
+	// which can be set by any client, so that a bogus value neither skews the
+	// histogram nor backdates the server span by years.
+	maxQueueTime = 5 * time.Minute
+)
+
+// queueStartHeaders are the headers set by load balancers and routers with
+// the time a request was received, in order of preference.
+var queueStartHeaders = []string{"X-Request-Start", "X-Queue-Start"}
+
+// queueStart returns the time the request was received by the load balancer
+// in front of the server, as set in the X-Request-Start or X-Queue-Start
+// header. The value can be prefixed with "t=", as nginx and Heroku do, and be
+// expressed in seconds (with an optional fractional part), milliseconds,
+// microseconds or nanoseconds since the Unix epoch.
+func queueStart(h http.Header) (time.Time, bool) {
+	for _, name := range queueStartHeaders {
+		v := strings.TrimPrefix(strings.TrimSpace(h.Get(name)), "t=")
+		if v == "" {
+			continue
+		}
+		f, err := strconv.ParseFloat(v, 64)
+		if err != nil || f <= 0 {
+			continue
+		}
+		var nanos float64
+		switch {
+		case f >= 1e18:
+			nanos = f
+		case f >= 1e15:
+			nanos = f * 1e3
+		case f >= 1e12:
+			nanos = f * 1e6
+		default:
+			nanos = f * 1e9
+		}
+		if nanos >= math.MaxInt64 {
+			continue
+		}
+		return time.Unix(0, int64(nanos)), true
+	}
+	return time.Time{}, false
+}
+
+// queuedFor returns the time a request received by the load balancer at
+// start spent queued until now, if start is neither in the future nor more
+// than maxQueueTime ago.
+func queuedFor(start, now time.Time) (time.Duration, bool) {
+	d := now.Sub(start)
+	return d, d > 0 && d <= maxQueueTime
+}
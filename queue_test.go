@@ -0,0 +1,141 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package otelgin
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestQueueStart(t *testing.T) {
+	want := time.Date(2024, 3, 27, 10, 0, 0, 123000000, time.UTC)
+	tests := []struct {
+		header string
+		value  string
+		ok     bool
+	}{
+		{"X-Request-Start", "t=1711533600.123", true},
+		{"X-Request-Start", "t=1711533600123", true},
+		{"X-Request-Start", "t=1711533600123000", true},
+		{"X-Queue-Start", "1711533600123000000", true},
+		{"X-Request-Start", "t=garbage", false},
+		{"X-Request-Start", "", false},
+	}
+	for _, tt := range tests {
+		h := http.Header{}
+		h.Set(tt.header, tt.value)
+		got, ok := queueStart(h)
+		assert.Equal(t, tt.ok, ok, tt.value)
+		if tt.ok {
+			assert.WithinDuration(t, want, got, time.Microsecond, tt.value)
+		}
+	}
+}
+
+func TestQueueTime(t *testing.T) {
+	sr := tracetest.NewSpanRecorder()
+	tracerProvider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(sr))
+	reader := metric.NewManualReader()
+	meterProvider := metric.NewMeterProvider(metric.WithReader(reader))
+	defer func() {
+		_ = meterProvider.Shutdown(context.Background())
+	}()
+
+	router := gin.New()
+	router.Use(Middleware("test-service",
+		WithTracerProvider(tracerProvider),
+		WithMeterProvider(meterProvider),
+		WithQueueTimeSpanStart(true),
+	))
+	router.GET("/ping", func(c *gin.Context) {})
+
+	start := time.Now().Add(-250 * time.Millisecond)
+	r := httptest.NewRequest("GET", "/ping", nil)
+	r.Header.Set("X-Request-Start", fmt.Sprintf("t=%d", start.UnixMicro()))
+	router.ServeHTTP(httptest.NewRecorder(), r)
+
+	spans := sr.Ended()
+	require.Len(t, spans, 1)
+	assert.WithinDuration(t, start, spans[0].StartTime(), time.Microsecond)
+	attrs := attribute.NewSet(spans[0].Attributes()...)
+	v, ok := attrs.Value(queueTimeKey)
+	assert.True(t, ok)
+	assert.GreaterOrEqual(t, v.AsFloat64(), 250.0)
+
+	var rm metricdata.ResourceMetrics
+	require.NoError(t, reader.Collect(context.Background(), &rm))
+	var count uint64
+	for _, sm := range rm.ScopeMetrics {
+		for _, m := range sm.Metrics {
+			if m.Name != queueTimeName {
+				continue
+			}
+			for _, dp := range m.Data.(metricdata.Histogram[float64]).DataPoints {
+				route, _ := dp.Attributes.Value("http.route")
+				assert.Equal(t, "/ping", route.AsString())
+				assert.GreaterOrEqual(t, dp.Sum, 250.0)
+				count += dp.Count
+			}
+		}
+	}
+	assert.Equal(t, uint64(1), count)
+}
+
+func TestQueueStartOverflow(t *testing.T) {
+	h := http.Header{}
+	h.Set("X-Queue-Start", "1e300")
+	_, ok := queueStart(h)
+	assert.False(t, ok)
+}
+
+func TestQueuedFor(t *testing.T) {
+	now := time.Now()
+	d, ok := queuedFor(now.Add(-time.Second), now)
+	assert.True(t, ok)
+	assert.Equal(t, time.Second, d)
+
+	_, ok = queuedFor(now.Add(time.Second), now)
+	assert.False(t, ok, "future")
+	_, ok = queuedFor(now.Add(-maxQueueTime-time.Second), now)
+	assert.False(t, ok, "too old")
+}
+
+func TestQueueTimeBogusHeader(t *testing.T) {
+	sr := tracetest.NewSpanRecorder()
+	tracerProvider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(sr))
+
+	router := gin.New()
+	router.Use(Middleware("test-service",
+		WithTracerProvider(tracerProvider),
+		WithQueueTimeSpanStart(true),
+	))
+	router.GET("/ping", func(c *gin.Context) {})
+
+	for _, v := range []string{"t=1", "t=1e300"} {
+		r := httptest.NewRequest("GET", "/ping", nil)
+		r.Header.Set("X-Request-Start", v)
+		before := time.Now()
+		router.ServeHTTP(httptest.NewRecorder(), r)
+
+		spans := sr.Ended()
+		span := spans[len(spans)-1]
+		assert.False(t, span.StartTime().Before(before), v)
+		attrs := attribute.NewSet(span.Attributes()...)
+		assert.False(t, attrs.HasValue(queueTimeKey), v)
+	}
+}
@@ -0,0 +1,71 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package otelgin // import "go.opentelemetry.io/contrib/instrumentation/github.com/gin-gonic/gin/otelgin"
+
+import (
+	"crypto/rand"
+	"fmt"
+
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+const (
+	requestIDKey = attribute.Key("http.request.id")
+
+	// requestIDContextKey is the key of the request ID in the gin.Context.
+	requestIDContextKey = "otel-go-contrib-request-id"
+
+	defaultRequestIDHeader = "X-Request-ID"
+
+	// maxRequestIDLen bounds the length of the incoming request IDs.
+	maxRequestIDLen = 128
+)
+
+// RequestIDGenerator generates the ID of a request coming without one.
+type RequestIDGenerator func() string
+
+// newRequestID returns a random UUID.
+func newRequestID() string {
+	var b [16]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		otel.Handle(err)
+	}
+	b[6] = b[6]&0x0f | 0x40 // Version 4.
+	b[8] = b[8]&0x3f | 0x80 // Variant RFC 4122.
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:])
+}
+
+// validRequestID reports whether id, coming from a client, is a request ID
+// safe to record and echo: non-empty, bounded and made of printable ASCII.
+func validRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLen {
+		return false
+	}
+	for i := 0; i < len(id); i++ {
+		if id[i] < 0x21 || id[i] > 0x7e {
+			return false
+		}
+	}
+	return true
+}
+
+// requestID returns the ID of the request served by ctx, read from its header
+// or generated, stores it in ctx and echoes it on the response.
+func (c *config) requestID(ctx *gin.Context) string {
+	id := ctx.GetHeader(c.RequestIDHeader)
+	if !validRequestID(id) {
+		id = c.RequestIDGenerator()
+	}
+	ctx.Set(requestIDContextKey, id)
+	ctx.Header(c.RequestIDHeader, id)
+	return id
+}
+
+// RequestID returns the ID of the request served by c, set by a Middleware
+// created with the WithRequestID option, or "" if there is none.
+func RequestID(c *gin.Context) string {
+	return c.GetString(requestIDContextKey)
+}
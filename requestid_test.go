@@ -0,0 +1,97 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package otelgin
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestRequestID(t *testing.T) {
+	sr := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(sr))
+
+	var got string
+	router := gin.New()
+	router.Use(Middleware("test-service",
+		WithTracerProvider(provider),
+		WithRequestID(""),
+	))
+	router.GET("/user/:id", func(c *gin.Context) {
+		got = RequestID(c)
+	})
+
+	r := httptest.NewRequest("GET", "/user/123", nil)
+	r.Header.Set("X-Request-ID", "abc-123")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, r)
+
+	assert.Equal(t, "abc-123", got)
+	assert.Equal(t, "abc-123", w.Header().Get("X-Request-ID"))
+	spans := sr.Ended()
+	require.Len(t, spans, 1)
+	assert.Contains(t, spans[0].Attributes(), requestIDKey.String("abc-123"))
+}
+
+func TestRequestIDGenerated(t *testing.T) {
+	uuid := regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`)
+	for _, incoming := range []string{"", "has space", strings.Repeat("x", maxRequestIDLen+1)} {
+		var got string
+		router := gin.New()
+		router.Use(Middleware("test-service", WithRequestID("")))
+		router.GET("/", func(c *gin.Context) {
+			got = RequestID(c)
+		})
+
+		r := httptest.NewRequest("GET", "/", nil)
+		r.Header.Set("X-Request-ID", incoming)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, r)
+
+		assert.Regexp(t, uuid, got, incoming)
+		assert.Equal(t, got, w.Header().Get("X-Request-ID"), incoming)
+	}
+}
+
+func TestRequestIDCustom(t *testing.T) {
+	router := gin.New()
+	router.Use(Middleware("test-service",
+		WithRequestID("X-Correlation-ID"),
+		WithRequestIDGenerator(func() string { return "generated" }),
+		WithFilter(func(*http.Request) bool { return false }),
+	))
+	router.GET("/health", func(c *gin.Context) {
+		assert.Equal(t, "generated", RequestID(c))
+	})
+
+	r := httptest.NewRequest("GET", "/health", nil)
+	r.Header.Set("X-Request-ID", "ignored")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, r)
+
+	assert.Equal(t, "generated", w.Header().Get("X-Correlation-ID"))
+	assert.Empty(t, w.Header().Get("X-Request-ID"))
+}
+
+func TestRequestIDDisabled(t *testing.T) {
+	router := gin.New()
+	router.Use(Middleware("test-service"))
+	router.GET("/", func(c *gin.Context) {
+		assert.Empty(t, RequestID(c))
+	})
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("GET", "/", nil))
+	assert.Empty(t, w.Header().Get("X-Request-ID"))
+}
@@ -0,0 +1,58 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package otelgin // import "go.opentelemetry.io/contrib/instrumentation/github.com/gin-gonic/gin/otelgin"
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+const (
+	unmatchedRouteKey = attribute.Key("gin.route.unmatched")
+	routeBucketKey    = attribute.Key("gin.route.bucket")
+)
+
+var (
+	unmatchedNotFound         = unmatchedRouteKey.String("not_found")
+	unmatchedMethodNotAllowed = unmatchedRouteKey.String("method_not_allowed")
+)
+
+// RouteBucketer maps the path of a request that matched no route to a
+// low-cardinality bucket, e.g. "/wp-admin/*" for scanner traffic. It returns
+// an empty string if the path belongs to no bucket.
+type RouteBucketer func(path string) string
+
+// unmatchedRoute returns the span name and the attributes describing a request
+// that matched no route. gin answers such a request with a 405 when
+// HandleMethodNotAllowed is enabled and another method matches the path, and
+// sets that status before running the NoMethod handlers, so it is already
+// known when the middleware runs.
+func unmatchedRoute(c *gin.Context, bucketer RouteBucketer) (string, []attribute.KeyValue) {
+	name := methodName(c.Request.Method)
+	attrs := []attribute.KeyValue{unmatchedNotFound}
+	if c.Writer.Status() == http.StatusMethodNotAllowed {
+		attrs[0] = unmatchedMethodNotAllowed
+	}
+	if bucketer != nil {
+		if bucket := bucketer(c.Request.URL.Path); bucket != "" {
+			name += " " + bucket
+			attrs = append(attrs, routeBucketKey.String(bucket))
+		}
+	}
+	return name, attrs
+}
+
+// methodName returns method if it is a known HTTP method, and "HTTP"
+// otherwise, so arbitrary methods sent by clients do not end up in span names.
+func methodName(method string) string {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodPost, http.MethodPut,
+		http.MethodPatch, http.MethodDelete, http.MethodConnect,
+		http.MethodOptions, http.MethodTrace:
+		return method
+	}
+	return "HTTP"
+}
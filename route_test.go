@@ -0,0 +1,109 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package otelgin
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+
+	"go.opentelemetry.io/otel/attribute"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestUnmatchedRoute(t *testing.T) {
+	bucketer := func(path string) string {
+		if strings.HasPrefix(path, "/wp-admin/") {
+			return "/wp-admin/*"
+		}
+		return ""
+	}
+	tests := []struct {
+		name          string
+		method        string
+		path          string
+		expectName    string
+		expectStatus  int
+		expectAttrs   []attribute.KeyValue
+		unexpectAttrs []attribute.Key
+	}{
+		{
+			name:          "not found",
+			method:        "GET",
+			path:          "/missing/123",
+			expectName:    "GET",
+			expectStatus:  http.StatusNotFound,
+			expectAttrs:   []attribute.KeyValue{unmatchedNotFound},
+			unexpectAttrs: []attribute.Key{"http.route", routeBucketKey},
+		},
+		{
+			name:          "method not allowed",
+			method:        "POST",
+			path:          "/user/123",
+			expectName:    "POST",
+			expectStatus:  http.StatusMethodNotAllowed,
+			expectAttrs:   []attribute.KeyValue{unmatchedMethodNotAllowed},
+			unexpectAttrs: []attribute.Key{"http.route"},
+		},
+		{
+			name:         "bucketed",
+			method:       "GET",
+			path:         "/wp-admin/setup.php",
+			expectName:   "GET /wp-admin/*",
+			expectStatus: http.StatusNotFound,
+			expectAttrs:  []attribute.KeyValue{unmatchedNotFound, routeBucketKey.String("/wp-admin/*")},
+		},
+		{
+			name:         "unknown method",
+			method:       "PROPFIND",
+			path:         "/missing",
+			expectName:   "HTTP",
+			expectStatus: http.StatusNotFound,
+			expectAttrs:  []attribute.KeyValue{unmatchedNotFound},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sr := tracetest.NewSpanRecorder()
+			provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(sr))
+
+			router := gin.New()
+			router.HandleMethodNotAllowed = true
+			router.Use(Middleware("test-service",
+				WithTracerProvider(provider),
+				WithSpanNameFormatter(func(r *http.Request) string { return r.URL.Path }),
+				WithUnmatchedRouteBucketer(bucketer),
+			))
+			router.GET("/user/:id", func(c *gin.Context) {})
+			router.NoRoute(func(c *gin.Context) {
+				c.String(http.StatusNotFound, "nothing here")
+			})
+
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest(tt.method, tt.path, nil))
+			assert.Equal(t, tt.expectStatus, w.Code)
+
+			spans := sr.Ended()
+			if !assert.Len(t, spans, 1) {
+				return
+			}
+			assert.Equal(t, tt.expectName, spans[0].Name())
+			attrs := attribute.NewSet(spans[0].Attributes()...)
+			for _, kv := range tt.expectAttrs {
+				v, ok := attrs.Value(kv.Key)
+				assert.True(t, ok, "missing attribute %s", kv.Key)
+				assert.Equal(t, kv.Value, v)
+			}
+			for _, k := range tt.unexpectAttrs {
+				assert.False(t, attrs.HasValue(k), "unexpected attribute %s", k)
+			}
+		})
+	}
+}
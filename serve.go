@@ -0,0 +1,151 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package otelgin // import "go.opentelemetry.io/contrib/instrumentation/github.com/gin-gonic/gin/otelgin"
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"sync/atomic"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	otelmetric "go.opentelemetry.io/otel/metric"
+	oteltrace "go.opentelemetry.io/otel/trace"
+)
+
+const (
+	shutdownDurationName = "http." + role + ".shutdown.duration"
+	abortedReqsName      = "http." + role + ".shutdown.aborted_requests"
+
+	abortedReqsKey = attribute.Key("http.server.shutdown.aborted_requests")
+
+	// defaultShutdownTimeout bounds the draining of in-flight requests
+	// when no timeout is given with WithShutdownTimeout.
+	defaultShutdownTimeout = 30 * time.Second
+)
+
+// flusher is implemented by the SDK tracer and meter providers.
+type flusher interface {
+	ForceFlush(context.Context) error
+	Shutdown(context.Context) error
+}
+
+// Serve serves engine on the TCP network address addr until ctx is done, and
+// then shuts down gracefully. The connections are instrumented with
+// InstrumentServer.
+//
+// On shutdown, the in-flight requests are drained for at most the timeout
+// given with WithShutdownTimeout, 30 seconds by default, within a
+// "gin.shutdown" span. The drain duration is recorded by the
+// http.server.shutdown.duration histogram and the requests still in flight
+// once the timeout is over are aborted and counted by the
+// http.server.shutdown.aborted_requests counter. The tracer and meter
+// providers given with WithTracerProvider and WithMeterProvider are then
+// flushed and shut down, if they support it, before Serve returns. The
+// global providers are left untouched.
+//
+// Serve returns the error that stopped the server, if it was not the
+// cancellation of ctx, joined with the errors of the shutdown.
+func Serve(ctx context.Context, engine *gin.Engine, addr string, opts ...Option) error {
+	cfg := config{ShutdownTimeout: defaultShutdownTimeout}
+	for _, opt := range opts {
+		opt.apply(&cfg)
+	}
+	// Only the providers configured by the caller are shut down.
+	tp, mp := cfg.TracerProvider, cfg.MeterProvider
+	if cfg.TracerProvider == nil {
+		cfg.TracerProvider = otel.GetTracerProvider()
+	}
+	if cfg.MeterProvider == nil {
+		cfg.MeterProvider = otel.GetMeterProvider()
+	}
+	tracer := cfg.TracerProvider.Tracer(
+		ScopeName,
+		oteltrace.WithInstrumentationVersion(Version()),
+	)
+	meter := cfg.MeterProvider.Meter(
+		ScopeName,
+		otelmetric.WithInstrumentationVersion(Version()),
+	)
+
+	shutdownDuration := cfg.float64Histogram(meter, shutdownDurationInstrument)
+	abortedReqs := cfg.int64Counter(meter, abortedReqsInstrument)
+
+	var inflight atomic.Int64
+	srv := &http.Server{
+		Addr: addr,
+		Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			inflight.Add(1)
+			defer inflight.Add(-1)
+			engine.ServeHTTP(w, r)
+		}),
+	}
+	InstrumentServer(srv, opts...)
+
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+	serveErr := make(chan error, 1)
+	go func() {
+		serveErr <- srv.Serve(ln)
+	}()
+
+	var errs []error
+	select {
+	case err := <-serveErr:
+		if !errors.Is(err, http.ErrServerClosed) {
+			errs = append(errs, err)
+		}
+	case <-ctx.Done():
+		errs = append(errs, drain(srv, tracer, cfg.ShutdownTimeout, &inflight, shutdownDuration, abortedReqs))
+		if err := <-serveErr; !errors.Is(err, http.ErrServerClosed) {
+			errs = append(errs, err)
+		}
+	}
+
+	// The context of the caller is done, so the providers get their own.
+	flushCtx, cancel := context.WithTimeout(context.Background(), cfg.ShutdownTimeout)
+	defer cancel()
+	for _, p := range []any{tp, mp} {
+		if f, ok := p.(flusher); ok {
+			errs = append(errs, f.ForceFlush(flushCtx), f.Shutdown(flushCtx))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// drain shuts srv down gracefully, closing it once timeout is over.
+func drain(srv *http.Server, tracer oteltrace.Tracer, timeout time.Duration, inflight *atomic.Int64,
+	shutdownDuration otelmetric.Float64Histogram, abortedReqs otelmetric.Int64Counter,
+) error {
+	ctx, span := tracer.Start(context.Background(), "gin.shutdown")
+	defer span.End()
+
+	shutdownCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	before := time.Now()
+	err := srv.Shutdown(shutdownCtx)
+	elapsedTime := float64(time.Since(before)) / float64(time.Millisecond)
+	shutdownDuration.Record(ctx, elapsedTime)
+
+	var aborted int64
+	if err != nil {
+		// The requests still in flight are aborted by closing their
+		// connections.
+		aborted = inflight.Load()
+		err = errors.Join(err, srv.Close())
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "shutdown timeout")
+	}
+	span.SetAttributes(abortedReqsKey.Int64(aborted))
+	abortedReqs.Add(ctx, aborted)
+	return err
+}
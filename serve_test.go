@@ -0,0 +1,133 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package otelgin
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+// memoryExporter keeps the last metrics it exported.
+type memoryExporter struct {
+	mu       sync.Mutex
+	last     metricdata.ResourceMetrics
+	shutdown bool
+}
+
+func (e *memoryExporter) Temporality(k metric.InstrumentKind) metricdata.Temporality {
+	return metric.DefaultTemporalitySelector(k)
+}
+
+func (e *memoryExporter) Aggregation(k metric.InstrumentKind) metric.Aggregation {
+	return metric.DefaultAggregationSelector(k)
+}
+
+func (e *memoryExporter) Export(_ context.Context, rm *metricdata.ResourceMetrics) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.last = *rm
+	return nil
+}
+
+func (e *memoryExporter) ForceFlush(context.Context) error { return nil }
+
+func (e *memoryExporter) Shutdown(context.Context) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.shutdown = true
+	return nil
+}
+
+func freeAddr(t *testing.T) string {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer ln.Close()
+	return ln.Addr().String()
+}
+
+func TestServe(t *testing.T) {
+	sr := tracetest.NewSpanRecorder()
+	tracerProvider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(sr))
+	exporter := &memoryExporter{}
+	meterProvider := metric.NewMeterProvider(metric.WithReader(metric.NewPeriodicReader(exporter)))
+
+	started := make(chan struct{})
+	release := make(chan struct{})
+	defer close(release)
+	router := gin.New()
+	router.GET("/slow", func(c *gin.Context) {
+		close(started)
+		<-release
+	})
+
+	addr := freeAddr(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	serveErr := make(chan error, 1)
+	go func() {
+		serveErr <- Serve(ctx, router, addr,
+			WithTracerProvider(tracerProvider),
+			WithMeterProvider(meterProvider),
+			WithShutdownTimeout(50*time.Millisecond),
+		)
+	}()
+
+	go func() {
+		// Retry until the server listens, and give up once it is
+		// shutting down.
+		for ctx.Err() == nil {
+			resp, err := http.Get("http://" + addr + "/slow")
+			if err == nil {
+				_ = resp.Body.Close()
+				return
+			}
+			time.Sleep(5 * time.Millisecond)
+		}
+	}()
+	<-started
+	cancel()
+
+	err := <-serveErr
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+
+	spans := sr.Ended()
+	require.Len(t, spans, 1)
+	assert.Equal(t, "gin.shutdown", spans[0].Name())
+	assert.Contains(t, spans[0].Attributes(), attribute.Int64(string(abortedReqsKey), 1))
+
+	exporter.mu.Lock()
+	defer exporter.mu.Unlock()
+	assert.True(t, exporter.shutdown, "Expected the meter provider to be shut down")
+	var aborted int64
+	var drains uint64
+	for _, sm := range exporter.last.ScopeMetrics {
+		for _, m := range sm.Metrics {
+			switch m.Name {
+			case abortedReqsName:
+				for _, dp := range m.Data.(metricdata.Sum[int64]).DataPoints {
+					aborted += dp.Value
+				}
+			case shutdownDurationName:
+				for _, dp := range m.Data.(metricdata.Histogram[float64]).DataPoints {
+					drains += dp.Count
+				}
+			}
+		}
+	}
+	assert.Equal(t, int64(1), aborted)
+	assert.Equal(t, uint64(1), drains)
+}
@@ -0,0 +1,112 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package otelgin // import "go.opentelemetry.io/contrib/instrumentation/github.com/gin-gonic/gin/otelgin"
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	otelmetric "go.opentelemetry.io/otel/metric"
+)
+
+const (
+	openConnsName    = "http." + role + ".open_connections"
+	connDurationName = "http." + role + ".connection.duration"
+	connRequestsName = "http." + role + ".connection.requests"
+
+	connStateKey = attribute.Key("http.connection.state")
+)
+
+// connInfo is what is tracked about an open connection.
+type connInfo struct {
+	state    http.ConnState
+	start    time.Time
+	requests int64
+}
+
+// connTracker records the connection metrics of an http.Server.
+type connTracker struct {
+	openConns    otelmetric.Int64UpDownCounter
+	connDuration otelmetric.Float64Histogram
+	connRequests otelmetric.Int64Histogram
+
+	mu    sync.Mutex
+	conns map[net.Conn]*connInfo
+}
+
+// InstrumentServer instruments the connections accepted by srv, typically the
+// server running a gin.Engine, by hooking its ConnState callback. A ConnState
+// callback already set on srv is still called. It reports the
+// http.server.open_connections gauge of the open connections by state, the
+// http.server.connection.duration histogram and the
+// http.server.connection.requests histogram of the requests served per
+// connection. Only the WithMeterProvider option is used.
+//
+// It must be called before srv starts serving.
+func InstrumentServer(srv *http.Server, opts ...Option) {
+	cfg := config{}
+	for _, opt := range opts {
+		opt.apply(&cfg)
+	}
+	if cfg.MeterProvider == nil {
+		cfg.MeterProvider = otel.GetMeterProvider()
+	}
+	meter := cfg.MeterProvider.Meter(
+		ScopeName,
+		otelmetric.WithInstrumentationVersion(Version()),
+	)
+
+	t := &connTracker{conns: make(map[net.Conn]*connInfo)}
+	t.openConns = cfg.int64UpDownCounter(meter, openConnsInstrument)
+	t.connDuration = cfg.float64Histogram(meter, connDurationInstrument)
+	t.connRequests = cfg.int64Histogram(meter, connRequestsInstrument)
+
+	next := srv.ConnState
+	srv.ConnState = func(conn net.Conn, state http.ConnState) {
+		t.connState(conn, state)
+		if next != nil {
+			next(conn, state)
+		}
+	}
+}
+
+func (t *connTracker) connState(conn net.Conn, state http.ConnState) {
+	ctx := context.Background()
+
+	t.mu.Lock()
+	info, ok := t.conns[conn]
+	if !ok {
+		info = &connInfo{state: state, start: time.Now()}
+		t.conns[conn] = info
+	}
+	prev := info.state
+	info.state = state
+	if state == http.StateActive {
+		info.requests++
+	}
+	closed := state == http.StateClosed || state == http.StateHijacked
+	if closed {
+		delete(t.conns, conn)
+	}
+	t.mu.Unlock()
+
+	if ok {
+		t.openConns.Add(ctx, -1, otelmetric.WithAttributes(connStateKey.String(prev.String())))
+	}
+	if !closed {
+		t.openConns.Add(ctx, one, otelmetric.WithAttributes(connStateKey.String(state.String())))
+		return
+	}
+	if !ok {
+		return
+	}
+	attrs := otelmetric.WithAttributes(connStateKey.String(state.String()))
+	t.connDuration.Record(ctx, float64(time.Since(info.start))/float64(time.Millisecond), attrs)
+	t.connRequests.Record(ctx, info.requests, attrs)
+}
@@ -0,0 +1,98 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package otelgin
+
+import (
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+)
+
+func TestInstrumentServer(t *testing.T) {
+	reader := metric.NewManualReader()
+	meterProvider := metric.NewMeterProvider(metric.WithReader(reader))
+	defer func() {
+		_ = meterProvider.Shutdown(context.Background())
+	}()
+
+	router := gin.New()
+	router.GET("/ping", func(c *gin.Context) {
+		c.String(http.StatusOK, "pong")
+	})
+	srv := httptest.NewUnstartedServer(router)
+	var hooked atomic.Bool
+	srv.Config.ConnState = func(net.Conn, http.ConnState) { hooked.Store(true) }
+	InstrumentServer(srv.Config, WithMeterProvider(meterProvider))
+	srv.Start()
+
+	client := srv.Client()
+	for i := 0; i < 2; i++ {
+		resp, err := client.Get(srv.URL + "/ping")
+		require.NoError(t, err)
+		_, _ = io.Copy(io.Discard, resp.Body)
+		_ = resp.Body.Close()
+	}
+	assert.Equal(t, int64(1), openConns(t, reader, "idle"))
+
+	srv.Close()
+	assert.True(t, hooked.Load(), "Expected the previous ConnState callback to be called")
+
+	var conns, requests int64
+	assert.Eventually(t, func() bool {
+		conns, requests = connRequests(t, reader)
+		return conns == 1
+	}, time.Second, 10*time.Millisecond)
+	assert.Equal(t, int64(2), requests)
+	assert.Equal(t, int64(0), openConns(t, reader, "idle"))
+}
+
+// connRequests returns the number of closed connections and the number of
+// requests they served.
+func connRequests(t *testing.T, reader metric.Reader) (conns, requests int64) {
+	var rm metricdata.ResourceMetrics
+	require.NoError(t, reader.Collect(context.Background(), &rm))
+	for _, sm := range rm.ScopeMetrics {
+		for _, m := range sm.Metrics {
+			if m.Name != connRequestsName {
+				continue
+			}
+			for _, dp := range m.Data.(metricdata.Histogram[int64]).DataPoints {
+				conns += int64(dp.Count)
+				requests += dp.Sum
+			}
+		}
+	}
+	return conns, requests
+}
+
+// openConns returns the number of open connections in state.
+func openConns(t *testing.T, reader metric.Reader, state string) int64 {
+	var rm metricdata.ResourceMetrics
+	require.NoError(t, reader.Collect(context.Background(), &rm))
+	for _, sm := range rm.ScopeMetrics {
+		for _, m := range sm.Metrics {
+			if m.Name != openConnsName {
+				continue
+			}
+			for _, dp := range m.Data.(metricdata.Sum[int64]).DataPoints {
+				if v, _ := dp.Attributes.Value(connStateKey); v.AsString() == state {
+					return dp.Value
+				}
+			}
+		}
+	}
+	return 0
+}
@@ -0,0 +1,59 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package otelgin // import "go.opentelemetry.io/contrib/instrumentation/github.com/gin-gonic/gin/otelgin"
+
+import (
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+)
+
+const (
+	sloReqsName = "http." + role + ".slo.requests"
+
+	sloOutcomeKey = attribute.Key("slo.outcome")
+	apdexLevelKey = attribute.Key("apdex.level")
+
+	// apdexToleratingFactor is the multiple of the threshold under which
+	// a request is tolerating rather than frustrated.
+	apdexToleratingFactor = 4
+)
+
+var (
+	sloGood  = sloOutcomeKey.String("good")
+	sloSlow  = sloOutcomeKey.String("slow")
+	sloError = sloOutcomeKey.String("error")
+
+	apdexSatisfied  = apdexLevelKey.String("satisfied")
+	apdexTolerating = apdexLevelKey.String("tolerating")
+	apdexFrustrated = apdexLevelKey.String("frustrated")
+)
+
+// sloAttrs classifies a request that took elapsed against threshold. A
+// failed request is an error for the SLO and frustrated for Apdex. Otherwise
+// it is good and satisfied within threshold, and slow beyond it, tolerating
+// within 4 times threshold and frustrated beyond.
+func sloAttrs(elapsed, threshold time.Duration, failed bool) []attribute.KeyValue {
+	switch {
+	case failed:
+		return []attribute.KeyValue{sloError, apdexFrustrated}
+	case elapsed <= threshold:
+		return []attribute.KeyValue{sloGood, apdexSatisfied}
+	case elapsed <= apdexToleratingFactor*threshold:
+		return []attribute.KeyValue{sloSlow, apdexTolerating}
+	default:
+		return []attribute.KeyValue{sloSlow, apdexFrustrated}
+	}
+}
+
+// sloThreshold returns the latency threshold of route, if any.
+func (c *config) sloThreshold(route string) (time.Duration, bool) {
+	if route == "" {
+		return 0, false
+	}
+	if t, ok := c.RouteSLOThresholds[route]; ok {
+		return t, t > 0
+	}
+	return c.SLOThreshold, c.SLOThreshold > 0
+}
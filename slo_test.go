@@ -0,0 +1,104 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package otelgin
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+)
+
+func TestSLOAttrs(t *testing.T) {
+	threshold := 100 * time.Millisecond
+	tests := []struct {
+		elapsed time.Duration
+		failed  bool
+		want    []attribute.KeyValue
+	}{
+		{50 * time.Millisecond, false, []attribute.KeyValue{sloGood, apdexSatisfied}},
+		{100 * time.Millisecond, false, []attribute.KeyValue{sloGood, apdexSatisfied}},
+		{300 * time.Millisecond, false, []attribute.KeyValue{sloSlow, apdexTolerating}},
+		{500 * time.Millisecond, false, []attribute.KeyValue{sloSlow, apdexFrustrated}},
+		{10 * time.Millisecond, true, []attribute.KeyValue{sloError, apdexFrustrated}},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, sloAttrs(tt.elapsed, threshold, tt.failed), tt.elapsed)
+	}
+}
+
+func TestRouteSLO(t *testing.T) {
+	reader := metric.NewManualReader()
+	meterProvider := metric.NewMeterProvider(metric.WithReader(reader))
+	defer func() {
+		_ = meterProvider.Shutdown(context.Background())
+	}()
+
+	router := gin.New()
+	router.Use(Middleware("test-service",
+		WithMeterProvider(meterProvider),
+		WithSLO(time.Hour),
+		WithRouteSLO("/slow", time.Nanosecond),
+	))
+	router.GET("/fast", func(c *gin.Context) {})
+	router.GET("/slow", func(c *gin.Context) { time.Sleep(time.Millisecond) })
+	router.GET("/fail", func(c *gin.Context) { c.Status(http.StatusInternalServerError) })
+
+	for _, path := range []string{"/fast", "/slow", "/fail", "/missing"} {
+		router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", path, nil))
+	}
+
+	var rm metricdata.ResourceMetrics
+	require.NoError(t, reader.Collect(context.Background(), &rm))
+	outcomes := map[string]string{}
+	for _, sm := range rm.ScopeMetrics {
+		for _, m := range sm.Metrics {
+			if m.Name != sloReqsName {
+				continue
+			}
+			for _, dp := range m.Data.(metricdata.Sum[int64]).DataPoints {
+				route, _ := dp.Attributes.Value("http.route")
+				outcome, _ := dp.Attributes.Value(sloOutcomeKey)
+				level, _ := dp.Attributes.Value(apdexLevelKey)
+				outcomes[route.AsString()] = outcome.AsString() + "/" + level.AsString()
+			}
+		}
+	}
+	assert.Equal(t, map[string]string{
+		"/fast": "good/satisfied",
+		"/slow": "slow/frustrated",
+		"/fail": "error/frustrated",
+	}, outcomes)
+}
+
+func TestSLOThreshold(t *testing.T) {
+	cfg := config{}
+	for _, opt := range []Option{
+		WithSLO(time.Second),
+		WithRouteSLO("/poll", 0),
+		WithRouteSLO("/fast", 10*time.Millisecond),
+	} {
+		opt.apply(&cfg)
+	}
+
+	threshold, ok := cfg.sloThreshold("/users")
+	assert.True(t, ok)
+	assert.Equal(t, time.Second, threshold)
+	threshold, ok = cfg.sloThreshold("/fast")
+	assert.True(t, ok)
+	assert.Equal(t, 10*time.Millisecond, threshold)
+	_, ok = cfg.sloThreshold("/poll")
+	assert.False(t, ok, "zero disables the SLO of the route")
+	_, ok = cfg.sloThreshold("")
+	assert.False(t, ok, "unmatched")
+}
@@ -0,0 +1,199 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package otelgin // import "go.opentelemetry.io/contrib/instrumentation/github.com/gin-gonic/gin/otelgin"
+
+import (
+	"bytes"
+	"context"
+	"runtime"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/otel/attribute"
+	otellog "go.opentelemetry.io/otel/log"
+	oteltrace "go.opentelemetry.io/otel/trace"
+)
+
+const (
+	stacktraceKey = attribute.Key("code.stacktrace")
+	elapsedKey    = attribute.Key("http.request.elapsed")
+
+	// defaultSlowRequestStackSize caps the stack captured for a slow
+	// request.
+	defaultSlowRequestStackSize = 64 << 10
+
+	// stackDumpInterval is how long a dump of all goroutines is shared by
+	// the slow requests reported.
+	stackDumpInterval = time.Second
+	// maxStackDumpSize caps the dump of all goroutines the stack of a slow
+	// request is found in. The goroutines past the cap are not reported.
+	maxStackDumpSize = 16 << 20
+)
+
+// goroutineID returns the ID of the calling goroutine, as found in the
+// header of its stack trace, e.g. "goroutine 18 [running]:".
+func goroutineID() string {
+	var buf [64]byte
+	b := buf[:runtime.Stack(buf[:], false)]
+	b = bytes.TrimPrefix(b, []byte("goroutine "))
+	if i := bytes.IndexByte(b, ' '); i > 0 {
+		b = b[:i]
+	}
+	if _, err := strconv.ParseUint(string(b), 10, 64); err != nil {
+		return ""
+	}
+	return string(b)
+}
+
+// goroutineStack returns the stack trace of the goroutine with the ID id,
+// or the stack traces of all goroutines if all is true, found in a dump of
+// all goroutines shared with the other slow requests, capped to size bytes.
+// It returns "" if the goroutine is not found.
+func goroutineStack(id string, all bool, size int) string {
+	header := []byte("goroutine " + id + " [")
+	dump := stackDumps.get(header)
+	stack := dump
+	if !all {
+		start := bytes.Index(dump, header)
+		if id == "" || start < 0 {
+			return ""
+		}
+		stack = dump[start:]
+		if end := bytes.Index(stack, []byte("\n\n")); end >= 0 {
+			stack = stack[:end]
+		}
+	}
+	if len(stack) > size {
+		stack = stack[:size]
+	}
+	return string(stack)
+}
+
+// stackDumpCache shares the dumps of all goroutines between the requests
+// going slow at the same time, as each dump stops the world for a time
+// growing with the number of goroutines. A dump is shared for
+// stackDumpInterval, so the stacks it reports may be that old.
+type stackDumpCache struct {
+	mu    sync.Mutex
+	dump  []byte
+	taken time.Time
+}
+
+var stackDumps = &stackDumpCache{}
+
+// get returns a dump of all goroutines, reusing the last one if it was taken
+// less than stackDumpInterval ago and contains header. A new dump is taken
+// otherwise, e.g. for a goroutine created since the last one.
+func (d *stackDumpCache) get(header []byte) []byte {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.dump != nil && time.Since(d.taken) < stackDumpInterval && bytes.Contains(d.dump, header) {
+		return d.dump
+	}
+	taken := time.Now()
+	d.dump, d.taken = dumpAllGoroutines(), taken
+	// Release the dump once it is no longer shared.
+	time.AfterFunc(stackDumpInterval, func() {
+		d.mu.Lock()
+		defer d.mu.Unlock()
+		if d.taken.Equal(taken) {
+			d.dump = nil
+		}
+	})
+	return d.dump
+}
+
+// dumpAllGoroutines returns the stack traces of all goroutines, truncated to
+// maxStackDumpSize bytes.
+func dumpAllGoroutines() []byte {
+	buf := make([]byte, 64<<10)
+	for {
+		n := runtime.Stack(buf, true)
+		if n < len(buf) || len(buf) >= maxStackDumpSize {
+			return buf[:n]
+		}
+		buf = make([]byte, min(2*len(buf), maxStackDumpSize))
+	}
+}
+
+// slowRequestWatch reports a request still being served once it exceeds a
+// threshold.
+type slowRequestWatch struct {
+	cfg       *config
+	ctx       context.Context
+	span      oteltrace.Span
+	threshold time.Duration
+	gid       string
+
+	// The request is described from the values captured when the watch
+	// starts, as c may be modified by the handlers while it is reported.
+	method  string
+	route   string
+	handler string
+}
+
+// watchSlowRequest starts watching the request served by c on the calling
+// goroutine. The returned function stops the watch, waiting for the report
+// to complete if the threshold was just exceeded, and must be deferred so
+// that it is called once c has been served, even if a handler panics.
+func watchSlowRequest(ctx context.Context, cfg *config, c *gin.Context, span oteltrace.Span, threshold time.Duration) func() {
+	w := &slowRequestWatch{
+		cfg:       cfg,
+		ctx:       ctx,
+		span:      span,
+		threshold: threshold,
+		gid:       goroutineID(),
+		method:    c.Request.Method,
+		route:     c.FullPath(),
+		handler:   c.HandlerName(),
+	}
+	done := make(chan struct{})
+	timer := time.AfterFunc(threshold, func() {
+		defer close(done)
+		w.report()
+	})
+	return func() {
+		if !timer.Stop() {
+			<-done
+		}
+	}
+}
+
+// report adds a "slow_request" event to the span and emits a log record,
+// both carrying the stack of the request.
+func (w *slowRequestWatch) report() {
+	stack := goroutineStack(w.gid, w.cfg.SlowRequestAllGoroutines, w.cfg.SlowRequestStackSize)
+	elapsed := float64(w.threshold) / float64(time.Millisecond)
+
+	w.span.AddEvent("slow_request", oteltrace.WithAttributes(
+		handlerKey.String(w.handler),
+		elapsedKey.Float64(elapsed),
+		stacktraceKey.String(stack),
+	))
+
+	var record otellog.Record
+	record.SetTimestamp(time.Now())
+	record.SetSeverity(otellog.SeverityWarn)
+	record.SetSeverityText("WARN")
+	record.SetEventName("slow_request")
+	record.SetBody(otellog.StringValue("slow request still being served"))
+	record.AddAttributes(
+		otellog.String(string(handlerKey), w.handler),
+		otellog.String("http.request.method", w.method),
+		otellog.String("http.route", w.route),
+		otellog.Float64(string(elapsedKey), elapsed),
+		otellog.String(string(stacktraceKey), stack),
+	)
+	w.cfg.logger.Emit(w.ctx, record)
+}
+
+// slowRequestThreshold returns the slow request threshold of route, if any.
+func (c *config) slowRequestThreshold(route string) (time.Duration, bool) {
+	if t, ok := c.RouteSlowRequestThresholds[route]; ok && route != "" {
+		return t, t > 0
+	}
+	return c.SlowRequestThreshold, c.SlowRequestThreshold > 0
+}
@@ -0,0 +1,192 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package otelgin
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"go.opentelemetry.io/otel/attribute"
+	otellog "go.opentelemetry.io/otel/log"
+	"go.opentelemetry.io/otel/log/embedded"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+// recordingLoggerProvider returns a recordingLogger for every scope.
+type recordingLoggerProvider struct {
+	embedded.LoggerProvider
+
+	logger *recordingLogger
+}
+
+func (p recordingLoggerProvider) Logger(string, ...otellog.LoggerOption) otellog.Logger {
+	return p.logger
+}
+
+// recordingLogger is a Logger keeping the emitted records.
+type recordingLogger struct {
+	embedded.Logger
+
+	mu      sync.Mutex
+	records []otellog.Record
+}
+
+func (l *recordingLogger) Emit(_ context.Context, record otellog.Record) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.records = append(l.records, record)
+}
+
+func (*recordingLogger) Enabled(context.Context, otellog.EnabledParameters) bool {
+	return true
+}
+
+func (l *recordingLogger) Records() []otellog.Record {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return append([]otellog.Record(nil), l.records...)
+}
+
+func sleepyHandler(*gin.Context) {
+	time.Sleep(50 * time.Millisecond)
+}
+
+func TestSlowRequest(t *testing.T) {
+	sr := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(sr))
+	logger := &recordingLogger{}
+
+	router := gin.New()
+	router.Use(Middleware("test-service",
+		WithTracerProvider(provider),
+		WithLoggerProvider(recordingLoggerProvider{logger: logger}),
+		WithSlowRequestThreshold(10*time.Millisecond),
+		WithRouteSlowRequestThreshold("/fast", 0),
+	))
+	router.GET("/slow", sleepyHandler)
+	router.GET("/fast", sleepyHandler)
+
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/slow", nil))
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/fast", nil))
+
+	spans := sr.Ended()
+	require.Len(t, spans, 2)
+	require.Len(t, spans[0].Events(), 1)
+	event := spans[0].Events()[0]
+	assert.Equal(t, "slow_request", event.Name)
+	assert.Contains(t, event.Attributes, handlerKey.String("github.com/Cyprinus12138/otelgin.sleepyHandler"))
+	assert.Contains(t, event.Attributes, elapsedKey.Float64(10))
+	eventAttrs := attribute.NewSet(event.Attributes...)
+	stack, ok := eventAttrs.Value(stacktraceKey)
+	require.True(t, ok)
+	assert.Contains(t, stack.AsString(), "goroutine ")
+	assert.Contains(t, stack.AsString(), "otelgin.sleepyHandler")
+	assert.Empty(t, spans[1].Events(), "route threshold disables the report")
+
+	records := logger.Records()
+	require.Len(t, records, 1)
+	assert.Equal(t, otellog.SeverityWarn, records[0].Severity())
+	assert.Equal(t, "slow_request", records[0].EventName())
+	attrs := map[string]otellog.Value{}
+	records[0].WalkAttributes(func(kv otellog.KeyValue) bool {
+		attrs[kv.Key] = kv.Value
+		return true
+	})
+	assert.Equal(t, "/slow", attrs["http.route"].AsString())
+	assert.Equal(t, "GET", attrs["http.request.method"].AsString())
+}
+
+func TestGoroutineStack(t *testing.T) {
+	id := goroutineID()
+	require.NotEmpty(t, id)
+
+	stack := goroutineStack(id, false, defaultSlowRequestStackSize)
+	assert.Contains(t, stack, "goroutine "+id+" [")
+	assert.Contains(t, stack, "TestGoroutineStack")
+	assert.NotContains(t, stack, "\n\ngoroutine ")
+
+	assert.Len(t, goroutineStack(id, true, 128), 128)
+	assert.Empty(t, goroutineStack("0", false, defaultSlowRequestStackSize))
+}
+
+func TestSlowRequestAmongManyGoroutines(t *testing.T) {
+	sr := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(sr))
+
+	// Idle goroutines created before the one serving the request come
+	// first in the dump of all goroutines, well past its size cap.
+	idle := make(chan struct{})
+	defer close(idle)
+	for i := 0; i < 2000; i++ {
+		go func() { <-idle }()
+	}
+
+	logger := &recordingLogger{}
+	router := gin.New()
+	router.Use(Middleware("test-service",
+		WithTracerProvider(provider),
+		WithLoggerProvider(recordingLoggerProvider{logger: logger}),
+		WithSlowRequestThreshold(10*time.Millisecond),
+	))
+	router.GET("/slow", func(*gin.Context) {
+		// Wait for the report, however long dumping the stacks takes.
+		assert.Eventually(t, func() bool { return len(logger.Records()) > 0 }, 10*time.Second, time.Millisecond)
+	})
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/slow", nil))
+	}()
+	<-done
+
+	spans := sr.Ended()
+	require.Len(t, spans, 1)
+	require.Len(t, spans[0].Events(), 1)
+	eventAttrs := attribute.NewSet(spans[0].Events()[0].Attributes...)
+	stack, ok := eventAttrs.Value(stacktraceKey)
+	require.True(t, ok)
+	assert.Contains(t, stack.AsString(), "TestSlowRequestAmongManyGoroutines")
+	assert.Len(t, regexp.MustCompile(`(?m)^goroutine \d+ \[`).FindAllString(stack.AsString(), -1), 1, "only the serving goroutine is reported")
+}
+
+func TestSlowRequestPanickingHandler(t *testing.T) {
+	logger := &recordingLogger{}
+	router := gin.New()
+	router.Use(gin.CustomRecovery(func(c *gin.Context, _ any) {
+		c.AbortWithStatus(http.StatusInternalServerError)
+	}))
+	router.Use(Middleware("test-service",
+		WithLoggerProvider(recordingLoggerProvider{logger: logger}),
+		WithSlowRequestThreshold(50*time.Millisecond),
+	))
+	router.GET("/panic", func(*gin.Context) { panic("boom") })
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("GET", "/panic", nil))
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+
+	time.Sleep(150 * time.Millisecond)
+	assert.Empty(t, logger.Records(), "a served request is not reported")
+}
+
+func TestStackDumpShared(t *testing.T) {
+	d := &stackDumpCache{}
+	header := []byte("goroutine " + goroutineID() + " [")
+	first := d.get(header)
+	require.Contains(t, string(first), string(header))
+	assert.Same(t, &first[0], &d.get(header)[0], "a recent dump is shared")
+	assert.NotSame(t, &first[0], &d.get([]byte("goroutine 0 ["))[0], "a dump missing the goroutine is taken again")
+	assert.LessOrEqual(t, len(dumpAllGoroutines()), maxStackDumpSize)
+}
@@ -0,0 +1,79 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package otelgin // import "go.opentelemetry.io/contrib/instrumentation/github.com/gin-gonic/gin/otelgin"
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/otel/attribute"
+	oteltrace "go.opentelemetry.io/otel/trace"
+)
+
+const (
+	deadlineKey          = attribute.Key("http.request.deadline")
+	deadlineRemainingKey = attribute.Key("http.request.deadline.remaining")
+	timeoutKey           = attribute.Key("http.request.timeout")
+	handlerKey           = attribute.Key("gin.handler")
+
+	// timeoutErrorType is the error.type of requests that failed because
+	// their deadline was exceeded.
+	timeoutErrorType = "timeout"
+)
+
+// deadlineAttrs returns the deadline of ctx and the time remaining until it,
+// in milliseconds, or nothing if ctx has no deadline.
+func deadlineAttrs(ctx context.Context) []attribute.KeyValue {
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		return nil
+	}
+	remaining := float64(time.Until(deadline)) / float64(time.Millisecond)
+	return []attribute.KeyValue{
+		deadlineKey.String(deadline.UTC().Format(time.RFC3339Nano)),
+		deadlineRemainingKey.Float64(remaining),
+	}
+}
+
+// deadlineExceeded reports whether serving c failed because the deadline of
+// the request was exceeded, either as seen from the request context or from
+// an error attached to c.
+func deadlineExceeded(c *gin.Context) bool {
+	if errors.Is(c.Request.Context().Err(), context.DeadlineExceeded) {
+		return true
+	}
+	for _, err := range c.Errors {
+		if errors.Is(err.Err, context.DeadlineExceeded) {
+			return true
+		}
+	}
+	return false
+}
+
+// watchTimeout adds a "timeout" event naming the route handler of c, the
+// last handler of its chain, to span if the deadline of ctx is exceeded while
+// c is still being served. The returned function stops the watch, waiting for
+// the event to be added if the deadline was just exceeded, and must be called
+// once c has been served.
+func watchTimeout(ctx context.Context, c *gin.Context, span oteltrace.Span, timeout time.Duration) func() {
+	handler := c.HandlerName()
+	done := make(chan struct{})
+	stop := context.AfterFunc(ctx, func() {
+		defer close(done)
+		if !errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			return
+		}
+		span.AddEvent("timeout", oteltrace.WithAttributes(
+			timeoutKey.Float64(float64(timeout)/float64(time.Millisecond)),
+			handlerKey.String(handler),
+		))
+	})
+	return func() {
+		if !stop() {
+			<-done
+		}
+	}
+}
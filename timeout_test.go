@@ -0,0 +1,97 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package otelgin
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func slowHandler(c *gin.Context) {
+	<-c.Request.Context().Done()
+	c.AbortWithStatus(http.StatusServiceUnavailable)
+}
+
+func TestWithTimeout(t *testing.T) {
+	sr := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(sr))
+
+	router := gin.New()
+	router.Use(Middleware("test-service",
+		WithTracerProvider(provider),
+		WithTimeout(10*time.Millisecond),
+	))
+	router.GET("/slow", slowHandler)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("GET", "/slow", nil))
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+
+	spans := sr.Ended()
+	require.Len(t, spans, 1)
+	span := spans[0]
+	assert.Equal(t, codes.Error, span.Status().Code)
+	assert.Contains(t, span.Attributes(), errorTypeKey.String("timeout"))
+
+	attrs := attribute.NewSet(span.Attributes()...)
+	assert.True(t, attrs.HasValue(deadlineKey))
+	remaining, _ := attrs.Value(deadlineRemainingKey)
+	assert.InDelta(t, 10, remaining.AsFloat64(), 10)
+
+	require.Len(t, span.Events(), 1)
+	event := span.Events()[0]
+	assert.Equal(t, "timeout", event.Name)
+	assert.Contains(t, event.Attributes, timeoutKey.Float64(10))
+	assert.Contains(t, event.Attributes, handlerKey.String("github.com/Cyprinus12138/otelgin.slowHandler"))
+}
+
+func TestDeadlineFromServer(t *testing.T) {
+	sr := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(sr))
+
+	router := gin.New()
+	router.Use(Middleware("test-service", WithTracerProvider(provider)))
+	router.GET("/fast", func(c *gin.Context) {})
+
+	// http.TimeoutHandler in front of the engine sets such a deadline.
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/fast", nil).WithContext(ctx))
+
+	spans := sr.Ended()
+	require.Len(t, spans, 1)
+	assert.Equal(t, codes.Unset, spans[0].Status().Code)
+	assert.Empty(t, spans[0].Events())
+	attrs := attribute.NewSet(spans[0].Attributes()...)
+	remaining, ok := attrs.Value(deadlineRemainingKey)
+	assert.True(t, ok)
+	assert.Greater(t, remaining.AsFloat64(), float64(50*time.Second/time.Millisecond))
+	assert.False(t, attrs.HasValue(errorTypeKey))
+}
+
+func TestTimeoutFilteredRequest(t *testing.T) {
+	router := gin.New()
+	router.Use(Middleware("test-service",
+		WithFilter(func(r *http.Request) bool { return r.URL.Path != "/health" }),
+		WithTimeout(time.Minute),
+	))
+	router.GET("/health", func(c *gin.Context) {
+		_, ok := c.Request.Context().Deadline()
+		assert.True(t, ok)
+	})
+
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/health", nil))
+}
@@ -0,0 +1,72 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package otelgin // import "go.opentelemetry.io/contrib/instrumentation/github.com/gin-gonic/gin/otelgin"
+
+import (
+	"crypto/tls"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+)
+
+const (
+	tlsProtocolVersionKey = attribute.Key("tls.protocol.version")
+	tlsCipherKey          = attribute.Key("tls.cipher")
+	tlsResumedKey         = attribute.Key("tls.resumed")
+	tlsServerNameKey      = attribute.Key("tls.server_name")
+	tlsClientSubjectKey   = attribute.Key("tls.client.subject")
+	tlsClientIssuerKey    = attribute.Key("tls.client.issuer")
+	tlsClientSerialKey    = attribute.Key("tls.client.serial")
+	tlsClientNotAfterKey  = attribute.Key("tls.client.not_after")
+)
+
+// tlsVersion returns the version of the TLS protocol, e.g. "1.3", or an
+// empty string if unknown.
+func tlsVersion(v uint16) string {
+	switch v {
+	case tls.VersionTLS10:
+		return "1.0"
+	case tls.VersionTLS11:
+		return "1.1"
+	case tls.VersionTLS12:
+		return "1.2"
+	case tls.VersionTLS13:
+		return "1.3"
+	}
+	return ""
+}
+
+// tlsSpanAttrs returns the attributes describing the TLS connection state of
+// a request, including the leaf certificate presented by the client, if any.
+func tlsSpanAttrs(state *tls.ConnectionState) []attribute.KeyValue {
+	attrs := make([]attribute.KeyValue, 0, 8)
+	if v := tlsVersion(state.Version); v != "" {
+		attrs = append(attrs, tlsProtocolVersionKey.String(v))
+	}
+	attrs = append(attrs,
+		tlsCipherKey.String(tls.CipherSuiteName(state.CipherSuite)),
+		tlsResumedKey.Bool(state.DidResume),
+	)
+	if state.ServerName != "" {
+		attrs = append(attrs, tlsServerNameKey.String(state.ServerName))
+	}
+	if len(state.PeerCertificates) > 0 {
+		cert := state.PeerCertificates[0]
+		attrs = append(attrs,
+			tlsClientSubjectKey.String(cert.Subject.String()),
+			tlsClientIssuerKey.String(cert.Issuer.String()),
+			tlsClientSerialKey.String(cert.SerialNumber.String()),
+			tlsClientNotAfterKey.String(cert.NotAfter.UTC().Format(time.RFC3339)),
+		)
+	}
+	return attrs
+}
+
+// tlsMetricAttrs returns the low-cardinality subset of tlsSpanAttrs.
+func tlsMetricAttrs(state *tls.ConnectionState) []attribute.KeyValue {
+	if v := tlsVersion(state.Version); v != "" {
+		return []attribute.KeyValue{tlsProtocolVersionKey.String(v)}
+	}
+	return nil
+}
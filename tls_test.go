@@ -0,0 +1,97 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package otelgin
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"math/big"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestTLSSpanAttrs(t *testing.T) {
+	cert := &x509.Certificate{
+		Subject:      pkix.Name{CommonName: "client"},
+		Issuer:       pkix.Name{CommonName: "ca"},
+		SerialNumber: big.NewInt(42),
+		NotAfter:     time.Date(2030, 1, 2, 3, 4, 5, 0, time.UTC),
+	}
+	state := &tls.ConnectionState{
+		Version:          tls.VersionTLS13,
+		CipherSuite:      tls.TLS_AES_128_GCM_SHA256,
+		DidResume:        true,
+		ServerName:       "api.example.com",
+		PeerCertificates: []*x509.Certificate{cert},
+	}
+
+	assert.Equal(t, []attribute.KeyValue{
+		tlsProtocolVersionKey.String("1.3"),
+		tlsCipherKey.String("TLS_AES_128_GCM_SHA256"),
+		tlsResumedKey.Bool(true),
+		tlsServerNameKey.String("api.example.com"),
+		tlsClientSubjectKey.String("CN=client"),
+		tlsClientIssuerKey.String("CN=ca"),
+		tlsClientSerialKey.String("42"),
+		tlsClientNotAfterKey.String("2030-01-02T03:04:05Z"),
+	}, tlsSpanAttrs(state))
+	assert.Equal(t, []attribute.KeyValue{tlsProtocolVersionKey.String("1.3")}, tlsMetricAttrs(state))
+}
+
+func TestTLSAttributes(t *testing.T) {
+	sr := tracetest.NewSpanRecorder()
+	tracerProvider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(sr))
+	reader := metric.NewManualReader()
+	meterProvider := metric.NewMeterProvider(metric.WithReader(reader))
+	defer func() {
+		_ = meterProvider.Shutdown(context.Background())
+	}()
+
+	router := gin.New()
+	router.Use(Middleware("test-service",
+		WithTracerProvider(tracerProvider),
+		WithMeterProvider(meterProvider),
+		WithTLSAttributes(true),
+		WithTLSMetricAttributes(true),
+	))
+	router.GET("/ping", func(c *gin.Context) {})
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "https://example.com/ping", nil))
+
+	spans := sr.Ended()
+	require.Len(t, spans, 1)
+	assert.Contains(t, spans[0].Attributes(), tlsProtocolVersionKey.String("1.2"))
+	assert.Contains(t, spans[0].Attributes(), tlsServerNameKey.String("example.com"))
+
+	var rm metricdata.ResourceMetrics
+	require.NoError(t, reader.Collect(context.Background(), &rm))
+	found := false
+	for _, sm := range rm.ScopeMetrics {
+		for _, m := range sm.Metrics {
+			data, ok := m.Data.(metricdata.Histogram[float64])
+			if !ok {
+				continue
+			}
+			for _, dp := range data.DataPoints {
+				found = true
+				v, _ := dp.Attributes.Value(tlsProtocolVersionKey)
+				assert.Equal(t, "1.2", v.AsString())
+				assert.False(t, dp.Attributes.HasValue(tlsCipherKey))
+			}
+		}
+	}
+	assert.True(t, found, "Expected a request duration data point")
+}
@@ -0,0 +1,161 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package otelgin // import "go.opentelemetry.io/contrib/instrumentation/github.com/gin-gonic/gin/otelgin"
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"syscall"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/otel/attribute"
+	semconv "go.opentelemetry.io/otel/semconv/v1.20.0"
+	oteltrace "go.opentelemetry.io/otel/trace"
+)
+
+const (
+	ttfbName        = "http." + role + ".time_to_first_byte"
+	writeErrorsName = "http." + role + ".response.write_errors"
+
+	ttfbKey              = attribute.Key("http.response.time_to_first_byte")
+	bodySizeKey          = attribute.Key("http.response.body.size")
+	attemptedBodySizeKey = attribute.Key("http.response.body.attempted_size")
+	exceptionMessageKey  = attribute.Key("exception.message")
+)
+
+// responseWriter wraps the gin.ResponseWriter of a request to find when its
+// response headers are sent and whether its response body could be written.
+// Being a gin.ResponseWriter, it preserves the http.Flusher, http.Hijacker,
+// http.CloseNotifier and Pusher of the wrapped writer.
+//
+// The middleware wraps the writer of every request traced or metered, so
+// that the errors writing response bodies, e.g. a broken pipe once the client
+// went away, are recorded as a "response.write_error" span event with their
+// `error.type` and the number of bytes written and attempted, and by the
+// http.server.response.write_errors counter by route.
+type responseWriter struct {
+	gin.ResponseWriter
+
+	span  oteltrace.Span
+	start time.Time
+	// headersEvent is whether a "response.headers_sent" event is added to
+	// span.
+	headersEvent bool
+
+	// headersSent is when the response headers were sent, zero if they
+	// were not.
+	headersSent time.Time
+	// attempted is the number of bytes of the response body the handlers
+	// tried to write.
+	attempted int
+	// writeErr is the first error writing the response body.
+	writeErr error
+}
+
+var _ gin.ResponseWriter = (*responseWriter)(nil)
+
+// wrapResponseWriter replaces the writer of c with a responseWriter for the
+// request started at start and traced by span, and returns it.
+func wrapResponseWriter(c *gin.Context, span oteltrace.Span, start time.Time, headersEvent bool) *responseWriter {
+	w := &responseWriter{ResponseWriter: c.Writer, span: span, start: start, headersEvent: headersEvent}
+	c.Writer = w
+	return w
+}
+
+// sendingHeaders records that the response headers are being sent, unless
+// they already were.
+func (w *responseWriter) sendingHeaders() {
+	if !w.headersSent.IsZero() || w.ResponseWriter.Written() {
+		return
+	}
+	w.headersSent = time.Now()
+	if w.headersEvent {
+		w.span.AddEvent("response.headers_sent", oteltrace.WithTimestamp(w.headersSent), oteltrace.WithAttributes(
+			semconv.HTTPStatusCode(w.ResponseWriter.Status()),
+			ttfbKey.Float64(w.timeToFirstByte()),
+		))
+	}
+}
+
+// wrote records the outcome of writing size bytes of the response body.
+func (w *responseWriter) wrote(size int, err error) {
+	w.attempted += size
+	if err != nil && w.writeErr == nil {
+		w.writeErr = err
+	}
+}
+
+// served records that the response headers not sent by the handlers are
+// being sent, as gin does once the request is served, and adds a
+// "response.write_error" event to the span if the response body could not
+// be written.
+func (w *responseWriter) served() {
+	w.sendingHeaders()
+	if w.writeErr != nil {
+		written := w.ResponseWriter.Size()
+		if written < 0 {
+			written = 0
+		}
+		w.span.AddEvent("response.write_error", oteltrace.WithAttributes(
+			errorTypeKey.String(writeErrorType(w.writeErr)),
+			exceptionMessageKey.String(w.writeErr.Error()),
+			bodySizeKey.Int(written),
+			attemptedBodySizeKey.Int(w.attempted),
+		))
+	}
+}
+
+// timeToFirstByte returns the time in milliseconds from the start of the
+// request to the sending of the response headers.
+func (w *responseWriter) timeToFirstByte() float64 {
+	return float64(w.headersSent.Sub(w.start)) / float64(time.Millisecond)
+}
+
+func (w *responseWriter) WriteHeaderNow() {
+	w.sendingHeaders()
+	w.ResponseWriter.WriteHeaderNow()
+}
+
+func (w *responseWriter) Write(data []byte) (int, error) {
+	w.sendingHeaders()
+	n, err := w.ResponseWriter.Write(data)
+	w.wrote(len(data), err)
+	return n, err
+}
+
+func (w *responseWriter) WriteString(s string) (int, error) {
+	w.sendingHeaders()
+	n, err := w.ResponseWriter.WriteString(s)
+	w.wrote(len(s), err)
+	return n, err
+}
+
+func (w *responseWriter) Flush() {
+	w.sendingHeaders()
+	w.ResponseWriter.Flush()
+}
+
+// Unwrap returns the wrapped writer, for http.ResponseController.
+func (w *responseWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// writeErrorType returns the `error.type` of err, an error writing a
+// response body.
+func writeErrorType(err error) string {
+	switch {
+	case errors.Is(err, syscall.EPIPE):
+		return "broken_pipe"
+	case errors.Is(err, syscall.ECONNRESET):
+		return "connection_reset"
+	case errors.Is(err, os.ErrDeadlineExceeded):
+		return "write_timeout"
+	case errors.Is(err, http.ErrHijacked):
+		return "hijacked"
+	}
+	return fmt.Sprintf("%T", err)
+}
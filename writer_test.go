@@ -0,0 +1,190 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package otelgin
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestTimeToFirstByte(t *testing.T) {
+	sr := tracetest.NewSpanRecorder()
+	tracerProvider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(sr))
+	reader := metric.NewManualReader()
+	meterProvider := metric.NewMeterProvider(metric.WithReader(reader))
+	defer func() {
+		_ = meterProvider.Shutdown(context.Background())
+	}()
+
+	router := gin.New()
+	router.Use(Middleware("test-service",
+		WithTracerProvider(tracerProvider),
+		WithMeterProvider(meterProvider),
+		WithTimeToFirstByte(),
+	))
+	router.GET("/stream", func(c *gin.Context) {
+		c.Status(http.StatusAccepted)
+		c.Writer.Flush()
+		time.Sleep(50 * time.Millisecond)
+		_, _ = c.Writer.WriteString("done")
+	})
+	router.GET("/empty", func(c *gin.Context) {
+		c.Status(http.StatusNoContent)
+	})
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("GET", "/stream", nil))
+	assert.Equal(t, http.StatusAccepted, w.Code)
+	assert.Equal(t, "done", w.Body.String())
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("GET", "/empty", nil))
+	assert.Equal(t, http.StatusNoContent, w.Code)
+
+	spans := sr.Ended()
+	require.Len(t, spans, 2)
+	for i, status := range []int{http.StatusAccepted, http.StatusNoContent} {
+		require.Len(t, spans[i].Events(), 1)
+		event := spans[i].Events()[0]
+		assert.Equal(t, "response.headers_sent", event.Name)
+		assert.Contains(t, event.Attributes, attribute.Int("http.status_code", status))
+	}
+	streamed := spans[0].Events()[0]
+	assert.Less(t, streamed.Time.Sub(spans[0].StartTime()), 50*time.Millisecond)
+	assert.GreaterOrEqual(t, spans[0].EndTime().Sub(streamed.Time), 50*time.Millisecond)
+
+	var rm metricdata.ResourceMetrics
+	require.NoError(t, reader.Collect(context.Background(), &rm))
+	sums := map[string]float64{}
+	for _, sm := range rm.ScopeMetrics {
+		for _, m := range sm.Metrics {
+			if m.Name != ttfbName {
+				continue
+			}
+			for _, dp := range m.Data.(metricdata.Histogram[float64]).DataPoints {
+				route, _ := dp.Attributes.Value("http.route")
+				assert.Equal(t, uint64(1), dp.Count)
+				sums[route.AsString()] = dp.Sum
+			}
+		}
+	}
+	require.Len(t, sums, 2)
+	assert.Less(t, sums["/stream"], 50.0)
+}
+
+func TestResponseWriterInterfaces(t *testing.T) {
+	router := gin.New()
+	router.Use(Middleware("test-service", WithTimeToFirstByte()))
+	router.GET("/", func(c *gin.Context) {
+		_, ok := c.Writer.(*responseWriter)
+		assert.True(t, ok)
+		assert.Implements(t, (*http.Flusher)(nil), c.Writer)
+		assert.Implements(t, (*http.Hijacker)(nil), c.Writer)
+		assert.Implements(t, (*http.CloseNotifier)(nil), c.Writer) // nolint:staticcheck
+		assert.NotPanics(t, func() { _ = c.Writer.Pusher() })
+		assert.NoError(t, http.NewResponseController(c.Writer).Flush())
+	})
+
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil))
+}
+
+// brokenPipeWriter is an http.ResponseWriter whose client goes away after
+// limit bytes of the response body.
+type brokenPipeWriter struct {
+	*httptest.ResponseRecorder
+	limit int
+}
+
+func (w *brokenPipeWriter) Write(b []byte) (int, error) {
+	if len(b) > w.limit {
+		n, _ := w.ResponseRecorder.Write(b[:w.limit])
+		w.limit = 0
+		return n, &net.OpError{Op: "write", Net: "tcp", Err: os.NewSyscallError("write", syscall.EPIPE)}
+	}
+	w.limit -= len(b)
+	return w.ResponseRecorder.Write(b)
+}
+
+func (w *brokenPipeWriter) WriteString(s string) (int, error) {
+	return w.Write([]byte(s))
+}
+
+func TestResponseWriteError(t *testing.T) {
+	sr := tracetest.NewSpanRecorder()
+	tracerProvider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(sr))
+	reader := metric.NewManualReader()
+	meterProvider := metric.NewMeterProvider(metric.WithReader(reader))
+	defer func() {
+		_ = meterProvider.Shutdown(context.Background())
+	}()
+
+	router := gin.New()
+	router.Use(Middleware("test-service",
+		WithTracerProvider(tracerProvider),
+		WithMeterProvider(meterProvider),
+	))
+	router.GET("/download", func(c *gin.Context) {
+		_, _ = c.Writer.WriteString("0123456789")
+		_, _ = c.Writer.WriteString("0123456789")
+	})
+
+	w := &brokenPipeWriter{ResponseRecorder: httptest.NewRecorder(), limit: 15}
+	router.ServeHTTP(w, httptest.NewRequest("GET", "/download", nil))
+
+	spans := sr.Ended()
+	require.Len(t, spans, 1)
+	span := spans[0]
+	assert.Equal(t, codes.Error, span.Status().Code)
+	assert.Contains(t, span.Attributes(), errorTypeKey.String("broken_pipe"))
+	require.Len(t, span.Events(), 1)
+	event := span.Events()[0]
+	assert.Equal(t, "response.write_error", event.Name)
+	assert.Contains(t, event.Attributes, errorTypeKey.String("broken_pipe"))
+	assert.Contains(t, event.Attributes, bodySizeKey.Int(15))
+	assert.Contains(t, event.Attributes, attemptedBodySizeKey.Int(20))
+
+	var rm metricdata.ResourceMetrics
+	require.NoError(t, reader.Collect(context.Background(), &rm))
+	var count int64
+	for _, sm := range rm.ScopeMetrics {
+		for _, m := range sm.Metrics {
+			if m.Name != writeErrorsName {
+				continue
+			}
+			for _, dp := range m.Data.(metricdata.Sum[int64]).DataPoints {
+				assert.Equal(t, attribute.NewSet(
+					errorTypeKey.String("broken_pipe"),
+					attribute.String("http.route", "/download"),
+				), dp.Attributes)
+				count += dp.Value
+			}
+		}
+	}
+	assert.Equal(t, int64(1), count)
+}
+
+func TestWriteErrorType(t *testing.T) {
+	assert.Equal(t, "broken_pipe", writeErrorType(&net.OpError{Err: os.NewSyscallError("write", syscall.EPIPE)}))
+	assert.Equal(t, "connection_reset", writeErrorType(syscall.ECONNRESET))
+	assert.Equal(t, "write_timeout", writeErrorType(os.ErrDeadlineExceeded))
+	assert.Equal(t, "hijacked", writeErrorType(http.ErrHijacked))
+	assert.Equal(t, "*errors.errorString", writeErrorType(errors.New("boom")))
+}